@@ -0,0 +1,184 @@
+package celestia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/rollkit/celestia-da/internal/telemetry"
+)
+
+// ErrInsufficientFunds is returned by Submit with BalanceConfig.Refuse if the last known balance of
+// the node account doesn't cover the estimated fee of a transaction.
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// BalanceConfig configures the monitoring of the balance of the node account.
+type BalanceConfig struct {
+	// Interval is the delay between balance queries.
+	Interval time.Duration `json:"interval"`
+	// WarnBelow logs a warning on every query while the balance, in utia, is lower. 0 disables it.
+	WarnBelow uint64 `json:"warn_below"`
+	// Refuse makes Submit fail with ErrInsufficientFunds instead of submitting a transaction whose
+	// estimated fee exceeds the last known balance.
+	Refuse bool `json:"refuse"`
+}
+
+// DefaultBalanceConfig returns the default balance monitoring configuration.
+func DefaultBalanceConfig() BalanceConfig {
+	return BalanceConfig{Interval: time.Minute}
+}
+
+// Balance is the balance of the node account.
+type Balance struct {
+	// Amount is the balance in utia. Fees of transactions submitted since Updated are deducted.
+	Amount  uint64    `json:"amount"`
+	Denom   string    `json:"denom"`
+	Updated time.Time `json:"updated"`
+	// Low is true if the balance is below BalanceConfig.WarnBelow.
+	Low bool `json:"low"`
+}
+
+// WithBalanceMonitor queries the balance of the node account every config.Interval, reports it as
+// the celestia_da_balance metric and logs warnings while it's low. The monitor is stopped by Close.
+func WithBalanceMonitor(config BalanceConfig) Option {
+	return func(c *CelestiaDA) error {
+		if config.Interval <= 0 {
+			return fmt.Errorf("invalid balance interval %s", config.Interval)
+		}
+		m := &balanceMonitor{config: config, done: make(chan struct{})}
+		reg, err := telemetry.Meter.RegisterCallback(m.observe, daMetrics.balance)
+		if err != nil {
+			return fmt.Errorf("failed to register balance metric: %w", err)
+		}
+		m.registration = reg
+		ctx, cancel := context.WithCancel(c.ctx)
+		m.cancel = cancel
+		c.balance = m
+		go c.monitorBalance(ctx)
+		return nil
+	}
+}
+
+// balanceMonitor holds the last known balance of the node account.
+type balanceMonitor struct {
+	config       BalanceConfig
+	registration metric.Registration
+	cancel       context.CancelFunc
+	done         chan struct{}
+
+	mu   sync.Mutex
+	last *Balance
+}
+
+// close stops the monitor.
+func (m *balanceMonitor) close() error {
+	m.cancel()
+	<-m.done
+	return m.registration.Unregister()
+}
+
+// get returns a copy of the last known balance, nil if the balance wasn't queried yet.
+func (m *balanceMonitor) get() *Balance {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.last == nil {
+		return nil
+	}
+	b := *m.last
+	return &b
+}
+
+// set replaces the last known balance with a copy of b.
+func (m *balanceMonitor) set(b *Balance) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	last := *b
+	m.last = &last
+}
+
+// spend deducts a fee from the last known balance until the next query.
+func (m *balanceMonitor) spend(fee uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.last == nil {
+		return
+	}
+	m.last.Amount -= min(fee, m.last.Amount)
+	m.last.Low = m.last.Amount < m.config.WarnBelow
+}
+
+func (m *balanceMonitor) observe(_ context.Context, o metric.Observer) error {
+	if b := m.get(); b != nil {
+		o.ObserveInt64(daMetrics.balance, int64(min(b.Amount, math.MaxInt64)), metric.WithAttributes(attribute.String("denom", b.Denom)))
+	}
+	return nil
+}
+
+// monitorBalance queries the balance until ctx is done.
+func (c *CelestiaDA) monitorBalance(ctx context.Context) {
+	defer close(c.balance.done)
+	for {
+		b, err := c.queryBalance(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			log.Println("failed to query balance", "error", err)
+		case err == nil:
+			c.balance.set(b)
+			if b.Low {
+				log.Println("WARNING: balance of the node account is low", "balance", b.Amount, "denom", b.Denom, "threshold", c.balance.config.WarnBelow)
+			}
+		}
+		select {
+		case <-time.After(c.balance.config.Interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// queryBalance requests the balance of the node account from the node.
+func (c *CelestiaDA) queryBalance(ctx context.Context) (*Balance, error) {
+	coin, err := c.client.State.Balance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	amount := uint64(math.MaxUint64)
+	if coin.Amount.IsUint64() {
+		amount = coin.Amount.Uint64()
+	}
+	b := &Balance{Amount: amount, Denom: coin.Denom, Updated: time.Now().UTC()}
+	if c.balance != nil {
+		b.Low = amount < c.balance.config.WarnBelow
+	}
+	return b, nil
+}
+
+// Balance returns the balance of the node account, as last queried with WithBalanceMonitor, or
+// as reported by the node.
+func (c *CelestiaDA) Balance(ctx context.Context) (*Balance, error) {
+	if c.balance != nil {
+		if b := c.balance.get(); b != nil {
+			return b, nil
+		}
+	}
+	return c.queryBalance(ctx)
+}
+
+// checkFunds returns ErrInsufficientFunds if submissions are refused with a last known balance
+// below fee.
+func (c *CelestiaDA) checkFunds(fee uint64) error {
+	if c.balance == nil || !c.balance.config.Refuse {
+		return nil
+	}
+	if b := c.balance.get(); b != nil && b.Amount < fee {
+		return fmt.Errorf("%w: estimated fee of %d utia exceeds the balance of %d %s", ErrInsufficientFunds, fee, b.Amount, b.Denom)
+	}
+	return nil
+}
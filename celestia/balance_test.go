@@ -0,0 +1,56 @@
+package celestia
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCelestiaDA_Balance(t *testing.T) {
+	m := setup(t)
+	defer teardown(m)
+	ctx := context.TODO()
+
+	// without monitoring, the balance is queried from the node
+	balance, err := m.Balance(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(mockBalance), balance.Amount)
+	assert.Equal(t, "utia", balance.Denom)
+	assert.False(t, balance.Low)
+
+	_, err = NewCelestiaDAWithOptions(m.client, m.namespace, -1, ctx, WithBalanceMonitor(BalanceConfig{}))
+	assert.Error(t, err)
+
+	config := BalanceConfig{Interval: 10 * time.Millisecond, WarnBelow: 1000, Refuse: true}
+	monitored, err := NewCelestiaDAWithOptions(m.client, m.namespace, -1, ctx, WithBalanceMonitor(config))
+	require.NoError(t, err)
+	defer monitored.Close()
+
+	_, fee := estimateFee([]uint32{uint32(len("blob"))}, -1)
+	m.s.state.setBalance(int64(fee) - 1)
+	assert.Eventually(t, func() bool {
+		balance, err := monitored.Balance(ctx)
+		return err == nil && balance.Amount == fee-1
+	}, time.Second, 10*time.Millisecond)
+	balance, err = monitored.Balance(ctx)
+	require.NoError(t, err)
+	assert.True(t, balance.Low)
+
+	height := m.s.blob.currentHeight()
+	_, err = monitored.Submit(ctx, []Blob{[]byte("blob")}, -1, nil)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	assert.Equal(t, height, m.s.blob.currentHeight(), "refused submissions aren't attempted")
+
+	m.s.state.setBalance(int64(fee) + 2000)
+	assert.Eventually(t, func() bool {
+		_, err := monitored.Submit(ctx, []Blob{[]byte("blob")}, -1, nil)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	// the fee is deducted until the next query
+	balance, err = monitored.Balance(ctx)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, balance.Amount, uint64(2000))
+}
@@ -0,0 +1,413 @@
+package celestia
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ErrCacheDisabled is returned by FlushCache if caching is disabled.
+var ErrCacheDisabled = errors.New("cache is disabled")
+
+// CacheConfig configures the cache of retrieved blobs, IDs and proofs.
+type CacheConfig struct {
+	// MaxBytes bounds the size of the in-memory cache.
+	MaxBytes int64 `json:"max_bytes"`
+	// Dir enables the on-disk tier, which holds the entries evicted from memory, in this directory.
+	Dir string `json:"dir"`
+	// MaxDiskBytes bounds the size of the on-disk tier, evicting the lowest heights first.
+	MaxDiskBytes int64 `json:"max_disk_bytes"`
+	// FinalityDepth is the number of heights below the local head of the node that aren't cached
+	// yet. Celestia blocks are final once committed, so 0 caches every height up to the head.
+	FinalityDepth uint64 `json:"finality_depth"`
+}
+
+// DefaultCacheConfig returns the default configuration of an in-memory cache.
+func DefaultCacheConfig() CacheConfig {
+	return CacheConfig{
+		MaxBytes:     64 << 20,
+		MaxDiskBytes: 1 << 30,
+	}
+}
+
+// WithCache caches the blobs, IDs and proofs retrieved by Get, GetIDs and GetProofs.
+//
+// Only heights at least config.FinalityDepth below the local head of the node are cached. When the
+// head of the node moves back, e.g. after the node was reset, entries above it are dropped. The
+// cache is closed by Close.
+func WithCache(config CacheConfig) Option {
+	return func(c *CelestiaDA) error {
+		if config.MaxBytes <= 0 {
+			return fmt.Errorf("invalid cache size %d", config.MaxBytes)
+		}
+		cache, err := newBlobCache(config)
+		if err != nil {
+			return err
+		}
+		c.cache = cache
+		return nil
+	}
+}
+
+// Cache entry kinds, which are part of the key.
+const (
+	cacheKindBlob  byte = 'b'
+	cacheKindIDs   byte = 'i'
+	cacheKindProof byte = 'p'
+)
+
+var cacheKindNames = map[byte]string{
+	cacheKindBlob:  "blob",
+	cacheKindIDs:   "ids",
+	cacheKindProof: "proof",
+}
+
+// cacheKey returns the key of a cache entry. Keys start with the big endian height, so the on-disk
+// tier is ordered by height.
+func cacheKey(kind byte, height uint64, ns share.Namespace, commitment []byte) string {
+	key := make([]byte, 0, heightLen+1+len(ns)+len(commitment))
+	key = binary.BigEndian.AppendUint64(key, height)
+	key = append(key, kind)
+	key = append(key, ns...)
+	return string(append(key, commitment...))
+}
+
+// cacheKeyHeight returns the height of a cache key.
+func cacheKeyHeight(key []byte) uint64 {
+	return binary.BigEndian.Uint64(key[:heightLen])
+}
+
+// cacheEntry is an entry of the in-memory tier.
+type cacheEntry struct {
+	key   string
+	value []byte
+}
+
+// blobCache is a least recently used cache bounded by bytes, with an optional on-disk tier.
+type blobCache struct {
+	config CacheConfig
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// lru holds the in-memory entries, most recently used first.
+	lru  *list.List
+	size int64
+
+	disk     *leveldb.DB
+	diskSize int64
+
+	// head is the local head of the node last observed.
+	head uint64
+}
+
+func newBlobCache(config CacheConfig) (*blobCache, error) {
+	cache := &blobCache{
+		config:  config,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+	if config.Dir == "" {
+		return cache, nil
+	}
+	db, err := leveldb.OpenFile(config.Dir, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache: %w", err)
+	}
+	iter := db.NewIterator(nil, nil)
+	for iter.Next() {
+		cache.diskSize += int64(len(iter.Key()) + len(iter.Value()))
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to read cache: %w", err), db.Close())
+	}
+	cache.disk = db
+	daMetrics.cacheBytes.Add(context.Background(), cache.diskSize, cacheTier("disk"))
+	return cache, nil
+}
+
+func (b *blobCache) close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	daMetrics.cacheBytes.Add(context.Background(), -b.size, cacheTier("memory"))
+	b.entries, b.size = make(map[string]*list.Element), 0
+	b.lru.Init()
+	if b.disk == nil {
+		return nil
+	}
+	daMetrics.cacheBytes.Add(context.Background(), -b.diskSize, cacheTier("disk"))
+	err := b.disk.Close()
+	b.disk, b.diskSize = nil, 0
+	return err
+}
+
+func cacheTier(tier string) metric.MeasurementOption {
+	return metric.WithAttributes(attribute.String("tier", tier))
+}
+
+// get returns the value of key, promoting entries of the on-disk tier to memory.
+func (b *blobCache) get(ctx context.Context, key string) ([]byte, bool) {
+	kind := attribute.String("kind", cacheKindNames[key[heightLen]])
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if elem, ok := b.entries[key]; ok {
+		b.lru.MoveToFront(elem)
+		daMetrics.cacheHits.Add(ctx, 1, metric.WithAttributes(kind, attribute.String("tier", "memory")))
+		return elem.Value.(*cacheEntry).value, true
+	}
+	if b.disk != nil {
+		value, err := b.disk.Get([]byte(key), nil)
+		if err == nil {
+			b.deleteDisk(ctx, []byte(key), value)
+			b.putMemory(ctx, key, value)
+			daMetrics.cacheHits.Add(ctx, 1, metric.WithAttributes(kind, attribute.String("tier", "disk")))
+			return value, true
+		}
+	}
+	daMetrics.cacheMisses.Add(ctx, 1, metric.WithAttributes(kind))
+	return nil, false
+}
+
+// put stores value under key, evicting the least recently used entries to the on-disk tier.
+func (b *blobCache) put(ctx context.Context, key string, value []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if cacheKeyHeight([]byte(key)) > b.head {
+		// the head moved back since the entry was determined to be final
+		return
+	}
+	b.putMemory(ctx, key, value)
+}
+
+func (b *blobCache) putMemory(ctx context.Context, key string, value []byte) {
+	size := int64(len(key) + len(value))
+	if size > b.config.MaxBytes {
+		return
+	}
+	if elem, ok := b.entries[key]; ok {
+		b.removeMemory(ctx, elem)
+	}
+	b.entries[key] = b.lru.PushFront(&cacheEntry{key: key, value: value})
+	b.size += size
+	daMetrics.cacheBytes.Add(ctx, size, cacheTier("memory"))
+	for b.size > b.config.MaxBytes {
+		oldest := b.lru.Back()
+		entry := oldest.Value.(*cacheEntry)
+		b.removeMemory(ctx, oldest)
+		b.putDisk(ctx, []byte(entry.key), entry.value)
+	}
+}
+
+func (b *blobCache) removeMemory(ctx context.Context, elem *list.Element) {
+	entry := elem.Value.(*cacheEntry)
+	b.lru.Remove(elem)
+	delete(b.entries, entry.key)
+	size := int64(len(entry.key) + len(entry.value))
+	b.size -= size
+	daMetrics.cacheBytes.Add(ctx, -size, cacheTier("memory"))
+}
+
+// putDisk stores an entry evicted from memory in the on-disk tier, and evicts the lowest heights
+// from it if it grows beyond MaxDiskBytes.
+func (b *blobCache) putDisk(ctx context.Context, key, value []byte) {
+	if b.disk == nil {
+		return
+	}
+	if old, err := b.disk.Get(key, nil); err == nil {
+		b.deleteDisk(ctx, key, old)
+	}
+	if err := b.disk.Put(key, value, nil); err != nil {
+		log.Println("failed to write cache entry", "error", err)
+		return
+	}
+	size := int64(len(key) + len(value))
+	b.diskSize += size
+	daMetrics.cacheBytes.Add(ctx, size, cacheTier("disk"))
+	if b.config.MaxDiskBytes <= 0 || b.diskSize <= b.config.MaxDiskBytes {
+		return
+	}
+	iter := b.disk.NewIterator(nil, nil)
+	defer iter.Release()
+	for b.diskSize > b.config.MaxDiskBytes && iter.Next() {
+		b.deleteDisk(ctx, bytes.Clone(iter.Key()), iter.Value())
+	}
+}
+
+func (b *blobCache) deleteDisk(ctx context.Context, key, value []byte) {
+	if err := b.disk.Delete(key, nil); err != nil {
+		log.Println("failed to delete cache entry", "error", err)
+		return
+	}
+	size := int64(len(key) + len(value))
+	b.diskSize -= size
+	daMetrics.cacheBytes.Add(ctx, -size, cacheTier("disk"))
+}
+
+// observeHead records the local head of the node, and drops the entries above it if it moved back.
+func (b *blobCache) observeHead(ctx context.Context, head uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if head < b.head {
+		b.invalidateAbove(ctx, head)
+	}
+	b.head = head
+}
+
+// invalidateAbove drops all entries above height.
+func (b *blobCache) invalidateAbove(ctx context.Context, height uint64) {
+	for elem := b.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if cacheKeyHeight([]byte(elem.Value.(*cacheEntry).key)) > height {
+			b.removeMemory(ctx, elem)
+		}
+		elem = next
+	}
+	if b.disk == nil {
+		return
+	}
+	start := binary.BigEndian.AppendUint64(nil, height+1)
+	iter := b.disk.NewIterator(&util.Range{Start: start}, nil)
+	defer iter.Release()
+	for iter.Next() {
+		b.deleteDisk(ctx, bytes.Clone(iter.Key()), iter.Value())
+	}
+}
+
+// flush drops all entries, and returns their number.
+func (b *blobCache) flush(ctx context.Context) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	count := b.lru.Len()
+	for b.lru.Len() > 0 {
+		b.removeMemory(ctx, b.lru.Front())
+	}
+	if b.disk == nil {
+		return count
+	}
+	iter := b.disk.NewIterator(nil, nil)
+	defer iter.Release()
+	for iter.Next() {
+		b.deleteDisk(ctx, bytes.Clone(iter.Key()), iter.Value())
+		count++
+	}
+	return count
+}
+
+// finalHead returns the last observed local head of the node.
+func (b *blobCache) finalHead() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.head
+}
+
+// FlushCache drops all entries of the cache, and returns their number. It returns
+// ErrCacheDisabled without WithCache.
+func (c *CelestiaDA) FlushCache(ctx context.Context) (int, error) {
+	if c.cache == nil {
+		return 0, ErrCacheDisabled
+	}
+	count := c.cache.flush(ctx)
+	log.Println("flushed cache", "entries", count)
+	return count, nil
+}
+
+// cacheable reports whether the data at height is final, and can be cached.
+func (c *CelestiaDA) cacheable(ctx context.Context, height uint64) bool {
+	if c.cache == nil || height == 0 {
+		return false
+	}
+	depth := c.cache.config.FinalityDepth
+	if height+depth <= c.cache.finalHead() {
+		return true
+	}
+	head, err := c.client.Header.LocalHead(ctx)
+	if err != nil {
+		return false
+	}
+	c.cache.observeHead(ctx, head.Height())
+	return height+depth <= head.Height()
+}
+
+// cacheGet returns a cached entry, if caching is enabled.
+func (c *CelestiaDA) cacheGet(ctx context.Context, kind byte, height uint64, ns share.Namespace, commitment []byte) ([]byte, bool) {
+	if c.cache == nil || height == 0 {
+		return nil, false
+	}
+	return c.cache.get(ctx, cacheKey(kind, height, ns, commitment))
+}
+
+// cachePut caches an entry, if caching is enabled and the height is final.
+func (c *CelestiaDA) cachePut(ctx context.Context, kind byte, height uint64, ns share.Namespace, commitment, value []byte) {
+	if !c.cacheable(ctx, height) {
+		return
+	}
+	c.cache.put(ctx, cacheKey(kind, height, ns, commitment), value)
+}
+
+// nodeBlob is a blob as stored by the node, before its transforms are reversed.
+type nodeBlob struct {
+	commitment []byte
+	data       []byte
+}
+
+// getBlob returns the data of a blob, from the cache if possible.
+func (c *CelestiaDA) getBlob(ctx context.Context, height uint64, ns share.Namespace, commitment []byte) ([]byte, error) {
+	if data, ok := c.cacheGet(ctx, cacheKindBlob, height, ns, commitment); ok {
+		return data, nil
+	}
+	b, err := c.client.Blob.Get(ctx, height, ns, commitment)
+	if err != nil {
+		return nil, c.heightError(ctx, height, err)
+	}
+	c.cachePut(ctx, cacheKindBlob, height, ns, commitment, b.Data)
+	return b.Data, nil
+}
+
+// cachedAll returns all blobs in the namespace at height from the cache, if the commitments and all
+// blobs are cached.
+func (c *CelestiaDA) cachedAll(ctx context.Context, height uint64, ns share.Namespace) ([]nodeBlob, bool) {
+	value, ok := c.cacheGet(ctx, cacheKindIDs, height, ns, nil)
+	if !ok {
+		return nil, false
+	}
+	var blobs []nodeBlob
+	for len(value) > 0 {
+		n, l := binary.Uvarint(value)
+		if l <= 0 || uint64(len(value)-l) < n {
+			return nil, false
+		}
+		commitment := value[l : l+int(n)]
+		value = value[l+int(n):]
+		data, ok := c.cacheGet(ctx, cacheKindBlob, height, ns, commitment)
+		if !ok {
+			return nil, false
+		}
+		blobs = append(blobs, nodeBlob{commitment: commitment, data: data})
+	}
+	return blobs, true
+}
+
+// cacheAll caches all blobs in the namespace at height.
+func (c *CelestiaDA) cacheAll(ctx context.Context, height uint64, ns share.Namespace, blobs []nodeBlob) {
+	if !c.cacheable(ctx, height) {
+		return
+	}
+	var value []byte
+	for _, b := range blobs {
+		value = binary.AppendUvarint(value, uint64(len(b.commitment)))
+		value = append(value, b.commitment...)
+		c.cache.put(ctx, cacheKey(cacheKindBlob, height, ns, b.commitment), b.data)
+	}
+	c.cache.put(ctx, cacheKey(cacheKindIDs, height, ns, nil), value)
+}
@@ -0,0 +1,124 @@
+package celestia
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	rpc "github.com/celestiaorg/celestia-node/api/rpc/client"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlobCache(t *testing.T) {
+	ctx := context.TODO()
+	ns, err := share.NewBlobNamespaceV0([]byte("cache"))
+	require.NoError(t, err)
+	value := bytes.Repeat([]byte{0x01}, 100)
+	key := func(height uint64) string { return cacheKey(cacheKindBlob, height, ns, []byte("commitment")) }
+	entrySize := int64(len(key(1)) + len(value))
+
+	config := CacheConfig{MaxBytes: 2 * entrySize, Dir: t.TempDir(), MaxDiskBytes: entrySize}
+	cache, err := newBlobCache(config)
+	require.NoError(t, err)
+	cache.observeHead(ctx, 10)
+
+	// heights 1 and 2 are evicted to disk, which only holds the highest of them
+	for height := uint64(1); height <= 4; height++ {
+		cache.put(ctx, key(height), value)
+	}
+	assert.Equal(t, 2*entrySize, cache.size)
+	assert.Equal(t, entrySize, cache.diskSize)
+	_, ok := cache.get(ctx, key(1))
+	assert.False(t, ok)
+
+	// promoting height 2 evicts the least recently used height 3 to disk
+	got, ok := cache.get(ctx, key(2))
+	require.True(t, ok)
+	assert.Equal(t, value, got)
+	_, ok = cache.get(ctx, key(3))
+	assert.True(t, ok)
+
+	// entries above the head aren't cached, and entries above a lower head are dropped
+	cache.put(ctx, key(11), value)
+	_, ok = cache.get(ctx, key(11))
+	assert.False(t, ok)
+	cache.observeHead(ctx, 2)
+	for height := uint64(3); height <= 4; height++ {
+		_, ok = cache.get(ctx, key(height))
+		assert.False(t, ok, height)
+	}
+	_, ok = cache.get(ctx, key(2))
+	assert.True(t, ok)
+
+	// the on-disk tier survives restarts
+	cache.put(ctx, key(1), value)
+	cache.put(ctx, cacheKey(cacheKindProof, 1, ns, []byte("commitment")), value)
+	assert.Equal(t, entrySize, cache.diskSize)
+	require.NoError(t, cache.close())
+	cache, err = newBlobCache(config)
+	require.NoError(t, err)
+	defer cache.close()
+	assert.Equal(t, entrySize, cache.diskSize)
+	cache.observeHead(ctx, 10)
+	_, ok = cache.get(ctx, key(2))
+	assert.True(t, ok)
+}
+
+func TestCelestiaDA_Cache(t *testing.T) {
+	m := setup(t)
+	defer teardown(m)
+	ctx := context.TODO()
+
+	client, err := rpc.NewClient(ctx, m.s.server.URL, "test")
+	require.NoError(t, err)
+	defer client.Close()
+	config := DefaultCacheConfig()
+	config.Dir = t.TempDir()
+	cached, err := NewCelestiaDAWithOptions(client, m.namespace, -1, ctx, WithCache(config))
+	require.NoError(t, err)
+	defer cached.Close()
+
+	ids, err := cached.Submit(ctx, []Blob{[]byte("blob")}, -1, nil)
+	require.NoError(t, err)
+	height, commitment := splitID(ids[0])
+	blobs, err := cached.Get(ctx, ids, nil)
+	require.NoError(t, err)
+	heightIDs, err := cached.GetIDs(ctx, height, nil)
+	require.NoError(t, err)
+	proofs, err := cached.GetProofs(ctx, ids, nil)
+	require.NoError(t, err)
+	// a height above the head isn't final yet
+	future := makeID(height+1, commitment)
+	_, err = cached.Get(ctx, []ID{future}, nil)
+	require.NoError(t, err)
+
+	m.s.blob.fail(height, errors.New("offline"))
+	m.s.blob.fail(height+1, errors.New("offline"))
+
+	got, err := cached.Get(ctx, ids, nil)
+	require.NoError(t, err)
+	assert.Equal(t, blobs, got)
+	gotIDs, err := cached.GetIDs(ctx, height, nil)
+	require.NoError(t, err)
+	assert.Equal(t, heightIDs, gotIDs)
+	gotProofs, err := cached.GetProofs(ctx, ids, nil)
+	require.NoError(t, err)
+	assert.Equal(t, proofs, gotProofs)
+	_, err = cached.Get(ctx, []ID{future}, nil)
+	assert.Error(t, err)
+
+	// flushed entries are requested from the node again
+	flushed, err := cached.FlushCache(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, flushed)
+	_, err = cached.Get(ctx, ids, nil)
+	assert.Error(t, err)
+	_, err = m.FlushCache(ctx)
+	assert.ErrorIs(t, err, ErrCacheDisabled)
+
+	_, err = NewCelestiaDAWithOptions(client, m.namespace, -1, ctx, WithCache(CacheConfig{}))
+	assert.Error(t, err)
+}
@@ -1,17 +1,22 @@
 package celestia
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
-	"strings"
+	"slices"
+	"time"
 
 	"github.com/celestiaorg/celestia-app/x/blob/types"
 	rpc "github.com/celestiaorg/celestia-node/api/rpc/client"
 	"github.com/celestiaorg/celestia-node/blob"
 	"github.com/celestiaorg/celestia-node/share"
 	"github.com/celestiaorg/nmt"
+	"github.com/syndtr/goleveldb/leveldb"
 
 	"github.com/rollkit/go-da"
 )
@@ -32,8 +37,80 @@ const (
 type CelestiaDA struct {
 	client    *rpc.Client
 	namespace share.Namespace
-	gasPrice  float64
 	ctx       context.Context
+
+	// runtime holds the default gas price and pause state, which can be changed while serving.
+	runtime *runtimeState
+
+	// transforms are applied to blobs in order before submission, and in reverse order on retrieval.
+	transforms []blobTransform
+
+	// chunking submits blobs larger than chunkSize, or MaxBlobSize if it is 0, as chunks and a manifest.
+	chunking  bool
+	chunkSize uint64
+
+	// cache holds retrieved blobs, IDs and proofs of final heights, nil disables caching.
+	cache *blobCache
+	// index records submitted blobs, nil disables indexing.
+	index *leveldb.DB
+	// finality makes Submit wait for the confirmation of submitted blobs, nil returns on inclusion.
+	finality *FinalityConfig
+	// balance monitors the balance of the node account, nil disables monitoring.
+	balance *balanceMonitor
+}
+
+// blobTransform encodes blobs before they are committed to and submitted, and decodes them on retrieval.
+type blobTransform interface {
+	encode(ns share.Namespace, data []byte) ([]byte, error)
+	decode(ns share.Namespace, data []byte) ([]byte, error)
+}
+
+// Option configures optional CelestiaDA features.
+type Option func(*CelestiaDA) error
+
+// WithCompression compresses blobs with the given algorithm before submission, and decompresses
+// blobs compressed with any algorithm in Get.
+//
+// CompressionNone only enables decompression, e.g. to keep reading blobs after compression was turned off.
+func WithCompression(algorithm Compression) Option {
+	return func(c *CelestiaDA) error {
+		compressor, err := newCompressor(algorithm)
+		if err != nil {
+			return err
+		}
+		c.transforms = append(c.transforms, compressor)
+		return nil
+	}
+}
+
+// WithEncryption encrypts blobs in namespaces with keys in the keyring before submission, and
+// decrypts them in Get.
+//
+// Options are applied in order, so WithCompression must precede WithEncryption for compression to be effective.
+func WithEncryption(keyring *Keyring) Option {
+	return func(c *CelestiaDA) error {
+		if keyring == nil {
+			return errors.New("encryption keyring is required")
+		}
+		c.transforms = append(c.transforms, &encryptor{keyring: keyring})
+		return nil
+	}
+}
+
+// WithChunking splits encoded blobs larger than chunkSize into ordered chunks, each submitted in its
+// own transaction, and submits a manifest referencing the chunks in their place. The returned ID is
+// the manifest ID, and Get reassembles and verifies the whole blob.
+//
+// A chunkSize of 0 uses MaxBlobSize. Commit returns ErrChunkedCommitment for blobs that would be chunked.
+func WithChunking(chunkSize uint64) Option {
+	return func(c *CelestiaDA) error {
+		if chunkSize != 0 && chunkSize < minChunkSize {
+			return fmt.Errorf("chunk size %d is smaller than the minimum of %d", chunkSize, minChunkSize)
+		}
+		c.chunking = true
+		c.chunkSize = chunkSize
+		return nil
+	}
 }
 
 // NewCelestiaDA returns an instance of CelestiaDA
@@ -41,16 +118,50 @@ func NewCelestiaDA(client *rpc.Client, namespace share.Namespace, gasPrice float
 	return &CelestiaDA{
 		client:    client,
 		namespace: namespace,
-		gasPrice:  gasPrice,
 		ctx:       ctx,
+		runtime:   &runtimeState{gasPrice: gasPrice},
+	}
+}
+
+// NewCelestiaDAWithOptions returns an instance of CelestiaDA with optional features enabled.
+func NewCelestiaDAWithOptions(client *rpc.Client, namespace share.Namespace, gasPrice float64, ctx context.Context, opts ...Option) (*CelestiaDA, error) {
+	c := NewCelestiaDA(client, namespace, gasPrice, ctx)
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, errors.Join(err, c.Close())
+		}
 	}
+	return c, nil
+}
+
+// Close releases the resources of optional features, such as the on-disk cache and the submission
+// index, and stops the balance monitor.
+func (c *CelestiaDA) Close() error {
+	var errs []error
+	if c.balance != nil {
+		errs = append(errs, c.balance.close())
+	}
+	if c.cache != nil {
+		errs = append(errs, c.cache.close())
+	}
+	if c.index != nil {
+		errs = append(errs, c.index.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Namespace returns the namespace used for requests without a namespace.
+func (c *CelestiaDA) Namespace() share.Namespace {
+	return c.namespace
 }
 
-func (c *CelestiaDA) defaultNamespace(ns da.Namespace) da.Namespace {
-	if ns == nil {
-		return c.namespace
+// defaultNamespace returns the validated namespace for a request, falling back to the
+// configured namespace if none is given.
+func (c *CelestiaDA) defaultNamespace(ns da.Namespace) (share.Namespace, error) {
+	if len(ns) == 0 {
+		return c.namespace, nil
 	}
-	return ns
+	return NamespaceFromBytes(ns)
 }
 
 // MaxBlobSize returns the max blob size
@@ -60,87 +171,399 @@ func (c *CelestiaDA) MaxBlobSize(ctx context.Context) (uint64, error) {
 }
 
 // Get returns Blob for each given ID, or an error.
+//
+// Errors for IDs the node doesn't have are ErrBlobNotFound, ErrHeightFromFuture or ErrHeightPruned.
 func (c *CelestiaDA) Get(ctx context.Context, ids []da.ID, ns da.Namespace) ([]da.Blob, error) {
-	c.namespace = c.defaultNamespace(ns)
+	namespace, err := c.defaultNamespace(ns)
+	if err != nil {
+		return nil, err
+	}
 	var blobs []da.Blob
 	for _, id := range ids {
 		height, commitment := splitID(id)
-		blob, err := c.client.Blob.Get(ctx, height, c.namespace, commitment)
+		data, err := c.getBlob(ctx, height, namespace, commitment)
 		if err != nil {
 			return nil, err
 		}
-		blobs = append(blobs, blob.Data)
+		data, err = c.decodeBlob(ctx, namespace, data)
+		if err != nil {
+			return nil, err
+		}
+		blobs = append(blobs, data)
 	}
 	return blobs, nil
 }
 
+// decodeBlob reassembles chunked blobs and reverses the configured blob transforms.
+func (c *CelestiaDA) decodeBlob(ctx context.Context, ns share.Namespace, data []byte) ([]byte, error) {
+	if c.chunking {
+		if bytes.HasPrefix(data, chunkMagic) {
+			return nil, ErrBlobIsChunk
+		}
+		if bytes.HasPrefix(data, manifestMagic) {
+			var err error
+			if data, err = c.reassemble(ctx, ns, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return c.decode(ns, data)
+}
+
 // GetIDs returns IDs of all Blobs located in DA at given height.
+//
+// Heights without blobs in the namespace return no IDs, heights the node doesn't have return
+// ErrHeightFromFuture or ErrHeightPruned.
 func (c *CelestiaDA) GetIDs(ctx context.Context, height uint64, ns da.Namespace) ([]da.ID, error) {
-	c.namespace = c.defaultNamespace(ns)
+	namespace, err := c.defaultNamespace(ns)
+	if err != nil {
+		return nil, err
+	}
 	var ids []da.ID
-	blobs, err := c.client.Blob.GetAll(ctx, height, []share.Namespace{c.namespace})
+	blobs, err := c.getAll(ctx, height, namespace)
 	if err != nil {
-		if strings.Contains(err.Error(), blob.ErrBlobNotFound.Error()) {
-			return nil, nil
-		}
 		return nil, err
 	}
 	for _, b := range blobs {
-		ids = append(ids, makeID(height, b.Commitment))
+		ids = append(ids, makeID(height, b.commitment))
 	}
 	return ids, nil
 }
 
+// getAll returns all blobs in the namespace at the given height, except chunks of larger blobs.
+func (c *CelestiaDA) getAll(ctx context.Context, height uint64, ns share.Namespace) ([]nodeBlob, error) {
+	blobs, err := c.getAllWithChunks(ctx, height, ns)
+	if err != nil || !c.chunking {
+		return blobs, err
+	}
+	filtered := make([]nodeBlob, 0, len(blobs))
+	for _, b := range blobs {
+		// chunks are only retrievable through their manifest
+		if !bytes.HasPrefix(b.data, chunkMagic) {
+			filtered = append(filtered, b)
+		}
+	}
+	return filtered, nil
+}
+
+// getAllWithChunks returns all blobs in the namespace at the given height, including chunks.
+func (c *CelestiaDA) getAllWithChunks(ctx context.Context, height uint64, ns share.Namespace) ([]nodeBlob, error) {
+	blobs, ok := c.cachedAll(ctx, height, ns)
+	if !ok {
+		var err error
+		if blobs, err = c.fetchAll(ctx, height, ns); err != nil {
+			return nil, err
+		}
+		c.cacheAll(ctx, height, ns, blobs)
+	}
+	return blobs, nil
+}
+
+// fetchAll requests all blobs in the namespace at the given height from the node.
+func (c *CelestiaDA) fetchAll(ctx context.Context, height uint64, ns share.Namespace) ([]nodeBlob, error) {
+	blobs, err := c.client.Blob.GetAll(ctx, height, []share.Namespace{ns})
+	if err != nil {
+		err = c.heightError(ctx, height, err)
+		if errors.Is(err, ErrBlobNotFound) {
+			// no blobs in the namespace at this height
+			return nil, nil
+		}
+		return nil, err
+	}
+	out := make([]nodeBlob, len(blobs))
+	for i, b := range blobs {
+		out[i] = nodeBlob{commitment: b.Commitment, data: b.Data}
+	}
+	return out, nil
+}
+
 // Commit creates a Commitment for each given Blob.
 func (c *CelestiaDA) Commit(ctx context.Context, daBlobs []da.Blob, ns da.Namespace) ([]da.Commitment, error) {
-	c.namespace = c.defaultNamespace(ns)
-	_, commitments, err := c.blobsAndCommitments(daBlobs, c.namespace)
+	namespace, err := c.defaultNamespace(ns)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.encodeBlobs(daBlobs, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if c.chunking {
+		chunkSize, err := c.maxChunkSize(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range data {
+			if needsChunking(d, chunkSize) {
+				return nil, ErrChunkedCommitment
+			}
+		}
+	}
+	_, commitments, err := c.blobsAndCommitments(data, namespace)
 	return commitments, err
 }
 
 // Submit submits the Blobs to Data Availability layer.
+//
+// Blobs that don't fit into a single transaction are submitted in order in multiple transactions,
+// and the returned IDs hold the height each blob was included at. If a transaction fails after
+// blobs were, or may have been, included, the IDs of the included blobs are returned along with a
+// *SubmitError. With WithFinality, Submit waits for the confirmation of the blobs. A negative
+// gasPrice uses the gas price of the service, see SetGasPrice. While paused, Submit fails with
+// ErrPaused.
 func (c *CelestiaDA) Submit(ctx context.Context, daBlobs []da.Blob, gasPrice float64, ns da.Namespace) ([]da.ID, error) {
-	c.namespace = c.defaultNamespace(ns)
-	blobs, _, err := c.blobsAndCommitments(daBlobs, c.namespace)
+	result, err := c.submit(ctx, daBlobs, gasPrice, ns, false, nil)
+	if result == nil {
+		return nil, err
+	}
+	return result.IDs, err
+}
+
+// ResumeSubmit resumes a submission that failed with a *SubmitError, given the blobs and namespace
+// of the submission and the progress of the error. Only the blobs and chunks that weren't included
+// are submitted, and the IDs of all blobs are returned like Submit does. If the failed transaction
+// may have been included, its blobs are looked up before submitting them again. A transaction that
+// is included after the lookup, e.g. because it was still held by the mempool, includes its blobs
+// twice.
+func (c *CelestiaDA) ResumeSubmit(ctx context.Context, daBlobs []da.Blob, gasPrice float64, ns da.Namespace, progress SubmitProgress) ([]da.ID, error) {
+	result, err := c.submit(ctx, daBlobs, gasPrice, ns, false, &progress)
+	if result == nil {
+		return nil, err
+	}
+	return result.IDs, err
+}
+
+type progressKey struct{}
+
+// ContextWithProgress returns a context that makes Submit and SubmitWithReceipts resume a failed
+// submission of the same blobs with its progress, like ResumeSubmit does.
+func ContextWithProgress(ctx context.Context, progress SubmitProgress) context.Context {
+	return context.WithValue(ctx, progressKey{}, progress)
+}
+
+// ProgressFromContext returns the progress set by ContextWithProgress, if any.
+func ProgressFromContext(ctx context.Context) (SubmitProgress, bool) {
+	progress, ok := ctx.Value(progressKey{}).(SubmitProgress)
+	return progress, ok
+}
+
+// submit implements Submit, ResumeSubmit and SubmitWithReceipts, shares resolves the share ranges of
+// receipts. resume is the progress of a failed submission of the same blobs, nil submits them all
+// unless ctx carries a progress.
+func (c *CelestiaDA) submit(ctx context.Context, daBlobs []da.Blob, gasPrice float64, ns da.Namespace, shares bool, resume *SubmitProgress) (*SubmitResult, error) {
+	if c.Paused() {
+		return nil, ErrPaused
+	}
+	if progress, ok := ProgressFromContext(ctx); ok && resume == nil {
+		resume = &progress
+	}
+	if gasPrice < 0 {
+		gasPrice = c.GasPrice()
+	}
+	namespace, err := c.defaultNamespace(ns)
 	if err != nil {
 		return nil, err
 	}
-	height, err := c.client.Blob.Submit(ctx, blobs, blob.GasPrice(gasPrice))
+	data, err := c.encodeBlobs(daBlobs, namespace)
 	if err != nil {
 		return nil, err
 	}
-	log.Println("successfully submitted blobs", "height", height, "gasPrice", gasPrice)
-	ids := make([]da.ID, len(blobs))
-	for i, blob := range blobs {
-		ids[i] = makeID(height, blob.Commitment)
+	if err := c.checkBlobSizes(ctx, data); err != nil {
+		return nil, err
 	}
-	return ids, nil
+	result := &SubmitResult{}
+	progress := &SubmitProgress{Chunks: make(map[int][]da.ID)}
+	if resume != nil {
+		result.IDs = resume.IDs
+		progress.Ambiguous, progress.Height = resume.Ambiguous, resume.Height
+		for i, ids := range resume.Chunks {
+			progress.Chunks[i] = slices.Clip(ids)
+		}
+	}
+	if c.chunking {
+		if data, err = c.submitChunks(ctx, data, namespace, gasPrice, shares, result, progress); err != nil {
+			return c.submitFailed(ctx, result, progress, len(daBlobs), err)
+		}
+	}
+	blobs, _, err := c.blobsAndCommitments(data, namespace)
+	if err != nil {
+		return c.submitFailed(ctx, result, progress, len(daBlobs), err)
+	}
+	if len(result.IDs) > len(blobs) {
+		return nil, fmt.Errorf("progress holds %d IDs for %d blobs", len(result.IDs), len(blobs))
+	}
+	maxSize, err := c.MaxBlobSize(ctx)
+	if err != nil {
+		return c.submitFailed(ctx, result, progress, len(daBlobs), err)
+	}
+	result.IDs = append(make([]da.ID, 0, len(blobs)), result.IDs...)
+	for len(result.IDs) < len(blobs) && progress.Ambiguous {
+		id, ok, err := c.lookupSubmitted(ctx, blobs[len(result.IDs)], namespace, progress.Height)
+		if err != nil {
+			return c.submitFailed(ctx, result, progress, len(daBlobs), err)
+		}
+		if !ok {
+			progress.Ambiguous = false
+			break
+		}
+		result.IDs = append(result.IDs, id)
+	}
+	for _, batch := range packBlobs(blobs[len(result.IDs):], maxSize) {
+		receipt, err := c.submitBlobs(ctx, batch, namespace, gasPrice, shares)
+		if err != nil {
+			return c.submitFailed(ctx, result, progress, len(daBlobs), err)
+		}
+		log.Println("successfully submitted blobs", "height", receipt.Height, "gasPrice", gasPrice, "count", len(batch), "txHash", receipt.TxHash)
+		result.Receipts = append(result.Receipts, receipt)
+		for _, b := range receipt.Blobs {
+			result.IDs = append(result.IDs, b.ID)
+		}
+	}
+	if c.finality != nil {
+		if err := c.confirm(ctx, result.IDs, namespace); err != nil {
+			result.NotFinal = true
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// failureHeadTimeout bounds the request for the head of the node after an ambiguous failure.
+const failureHeadTimeout = 5 * time.Second
+
+// submitFailed returns the result of a submission that failed with err. If blobs or chunks were
+// included, or the failed transaction may have been, the result is returned along with a
+// *SubmitError holding the progress.
+func (c *CelestiaDA) submitFailed(ctx context.Context, result *SubmitResult, progress *SubmitProgress, blobs int, err error) (*SubmitResult, error) {
+	var broadcastErr *broadcastError
+	if errors.As(err, &broadcastErr) {
+		progress.Ambiguous, progress.Height = true, 0
+		// the submission may have failed because ctx is done
+		headCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), failureHeadTimeout)
+		defer cancel()
+		if head, err := c.client.Header.LocalHead(headCtx); err == nil {
+			progress.Height = head.Height()
+		}
+	}
+	progress.IDs = result.IDs
+	if len(progress.IDs) == 0 && len(progress.Chunks) == 0 && !progress.Ambiguous {
+		return nil, err
+	}
+	return result, &SubmitError{Progress: *progress, Receipts: result.Receipts, Blobs: blobs, Err: err}
+}
+
+// submitLookback is the number of heights below the head at the failure of an ambiguous
+// transaction that are scanned for its blobs, as the node only reports the failure some time after
+// the transaction was sent.
+const submitLookback = 20
+
+// lookupSubmitted looks up a blob of a transaction that failed ambiguously at height, see
+// SubmitProgress, in the submission index or the blobs of the namespace since height.
+func (c *CelestiaDA) lookupSubmitted(ctx context.Context, b *blob.Blob, ns share.Namespace, height uint64) (da.ID, bool, error) {
+	commitment := da.Commitment(b.Commitment)
+	indexed, ok, err := c.indexedHeight(ctx, commitment, ns)
+	if err != nil {
+		return nil, false, err
+	}
+	if ok && indexed+submitLookback >= height {
+		return makeID(indexed, commitment), true, nil
+	}
+	depth := uint64(defaultCommitmentScanDepth)
+	if height > 0 {
+		head, err := c.client.Header.LocalHead(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+		if head.Height() >= height {
+			depth = min(head.Height()-height+submitLookback, maxCommitmentScanDepth)
+		}
+	}
+	found, ok, err := c.scanCommitment(ctx, commitment, ns, depth)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	log.Println("found blob of failed transaction", "height", found)
+	return makeID(found, commitment), true, nil
+}
+
+// submitBlobs submits blobs in a single transaction, and records them in the submission index.
+// shares resolves the share ranges of the receipt before indexing.
+func (c *CelestiaDA) submitBlobs(ctx context.Context, blobs []*blob.Blob, ns share.Namespace, gasPrice float64, shares bool) (*Receipt, error) {
+	receipt, err := c.payForBlobs(ctx, blobs, gasPrice)
+	if err != nil {
+		return nil, err
+	}
+	if shares {
+		c.resolveShares(ctx, receipt, ns)
+	}
+	c.indexSubmitted(ctx, receipt, ns, blobs, gasPrice)
+	return receipt, nil
 }
 
-// GetProofs returns the inclusion proofs for the given IDs.
+// GetProofs returns the inclusion proofs for the given IDs, with the same errors as Get.
 func (c *CelestiaDA) GetProofs(ctx context.Context, daIDs []da.ID, ns da.Namespace) ([]da.Proof, error) {
-	c.namespace = c.defaultNamespace(ns)
+	namespace, err := c.defaultNamespace(ns)
+	if err != nil {
+		return nil, err
+	}
 	proofs := make([]da.Proof, len(daIDs))
 	for i, id := range daIDs {
 		height, commitment := splitID(id)
-		proof, err := c.client.Blob.GetProof(ctx, height, c.namespace, commitment)
+		if cached, ok := c.cacheGet(ctx, cacheKindProof, height, namespace, commitment); ok {
+			proofs[i] = cached
+			continue
+		}
+		proof, err := c.client.Blob.GetProof(ctx, height, namespace, commitment)
 		if err != nil {
-			return nil, err
+			return nil, c.heightError(ctx, height, err)
 		}
 		proofs[i], err = json.Marshal(proof)
 		if err != nil {
 			return nil, err
 		}
+		c.cachePut(ctx, cacheKindProof, height, namespace, commitment, proofs[i])
 	}
 	return proofs, nil
 }
 
-// blobsAndCommitments converts []da.Blob to []*blob.Blob and generates corresponding []da.Commitment
-func (c *CelestiaDA) blobsAndCommitments(daBlobs []da.Blob, ns da.Namespace) ([]*blob.Blob, []da.Commitment, error) {
+// encodeBlobs applies the configured blob transforms to each blob, refusing blobs larger than
+// retrieval accepts after decoding.
+func (c *CelestiaDA) encodeBlobs(daBlobs []da.Blob, ns share.Namespace) ([][]byte, error) {
+	data := make([][]byte, len(daBlobs))
+	for i, daBlob := range daBlobs {
+		if len(daBlob) > maxDecodedBlobSize {
+			return nil, fmt.Errorf("%w: blob %d has %d bytes, the maximum is %d", ErrBlobTooLarge, i, len(daBlob), maxDecodedBlobSize)
+		}
+		var err error
+		if data[i], err = c.encode(ns, daBlob); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// checkBlobSizes refuses encoded blobs larger than the maximum blob size, unless they are chunked.
+func (c *CelestiaDA) checkBlobSizes(ctx context.Context, data [][]byte) error {
+	if c.chunking {
+		return nil
+	}
+	maxSize, err := c.MaxBlobSize(ctx)
+	if err != nil {
+		return err
+	}
+	for i, d := range data {
+		if uint64(len(d)) > maxSize {
+			return fmt.Errorf("%w: encoded blob %d has %d bytes, the maximum without chunking is %d", ErrBlobTooLarge, i, len(d), maxSize)
+		}
+	}
+	return nil
+}
+
+// blobsAndCommitments converts encoded blobs to []*blob.Blob and generates corresponding []da.Commitment
+func (c *CelestiaDA) blobsAndCommitments(data [][]byte, ns share.Namespace) ([]*blob.Blob, []da.Commitment, error) {
 	var blobs []*blob.Blob
 	var commitments []da.Commitment
-	for _, daBlob := range daBlobs {
-		b, err := blob.NewBlobV0(ns, daBlob)
+	for _, d := range data {
+		b, err := blob.NewBlobV0(ns, d)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -155,9 +578,34 @@ func (c *CelestiaDA) blobsAndCommitments(daBlobs []da.Blob, ns da.Namespace) ([]
 	return blobs, commitments, nil
 }
 
+// encode applies the configured blob transforms before submission.
+func (c *CelestiaDA) encode(ns share.Namespace, data []byte) ([]byte, error) {
+	var err error
+	for _, t := range c.transforms {
+		if data, err = t.encode(ns, data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// decode reverses the configured blob transforms after retrieval.
+func (c *CelestiaDA) decode(ns share.Namespace, data []byte) ([]byte, error) {
+	var err error
+	for i := len(c.transforms) - 1; i >= 0; i-- {
+		if data, err = c.transforms[i].decode(ns, data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
 // Validate validates Commitments against the corresponding Proofs. This should be possible without retrieving the Blobs.
 func (c *CelestiaDA) Validate(ctx context.Context, ids []da.ID, daProofs []da.Proof, ns da.Namespace) ([]bool, error) {
-	c.namespace = c.defaultNamespace(ns)
+	namespace, err := c.defaultNamespace(ns)
+	if err != nil {
+		return nil, err
+	}
 	var included []bool
 	var proofs []*blob.Proof
 	for _, daProof := range daProofs {
@@ -173,7 +621,7 @@ func (c *CelestiaDA) Validate(ctx context.Context, ids []da.ID, daProofs []da.Pr
 		// TODO(tzdybal): for some reason, if proof doesn't match commitment, API returns (false, "blob: invalid proof")
 		//    but analysis of the code in celestia-node implies this should never happen - maybe it's caused by openrpc?
 		//    there is no way of gently handling errors here, but returned value is fine for us
-		isIncluded, _ := c.client.Blob.Included(ctx, height, c.namespace, proofs[i], commitment)
+		isIncluded, _ := c.client.Blob.Included(ctx, height, namespace, proofs[i], commitment)
 		included = append(included, isIncluded)
 	}
 	return included, nil
@@ -130,7 +130,7 @@ func TestCelestiaDA(t *testing.T) {
 	})
 
 	t.Run("Submit_existing_with_gasprice_global", func(t *testing.T) {
-		m.CelestiaDA.gasPrice = 0.01
+		m.CelestiaDA.SetGasPrice(0.01)
 		blobs, err := m.Submit(ctx, []Blob{[]byte{0x00, 0x01, 0x02}}, -1, ns)
 		assert.NoError(t, err)
 		assert.Equal(t, 1, len(blobs))
@@ -0,0 +1,215 @@
+package celestia
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/celestiaorg/celestia-node/blob"
+	"github.com/celestiaorg/celestia-node/share"
+
+	"github.com/rollkit/go-da"
+)
+
+var (
+	// ErrChunkedCommitment is returned by Commit for blobs that would be chunked, as the commitment of
+	// a manifest depends on the heights its chunks are included at.
+	ErrChunkedCommitment = errors.New("commitment of a chunked blob is only known after submission")
+	// ErrChunkIntegrity is returned when a reassembled blob doesn't match its manifest.
+	ErrChunkIntegrity = errors.New("chunked blob integrity check failed")
+	// ErrBlobIsChunk is returned when a chunk of a larger blob is retrieved on its own.
+	ErrBlobIsChunk = errors.New("blob is a chunk of a larger blob, retrieve it by its manifest ID")
+)
+
+// manifestMagic prefixes manifests of chunked blobs.
+var manifestMagic = []byte{0x00, 'c', 'd', 'm'}
+
+// manifestVersion is the version of the manifest:
+//
+//	magic (4 bytes) | version (1 byte) | size (uvarint) | sha256 (32 bytes) | chunk count (uvarint) | chunk IDs
+//
+// with each chunk ID prefixed by its length as uvarint.
+const manifestVersion = 1
+
+// chunkMagic prefixes chunks of a larger blob.
+var chunkMagic = []byte{0x00, 'c', 'd', 'k'}
+
+// chunkVersion is the version of the chunk header:
+//
+//	magic (4 bytes) | version (1 byte) | index (4 bytes, big endian) | payload
+const chunkVersion = 1
+
+const chunkHeaderSize = 4 + 1 + 4
+
+// minChunkSize is the smallest chunk size accepted by WithChunking.
+const minChunkSize = 1024
+
+// maxChunkSize returns the maximum size of a blob before it is chunked.
+func (c *CelestiaDA) maxChunkSize(ctx context.Context) (uint64, error) {
+	if c.chunkSize != 0 {
+		return c.chunkSize, nil
+	}
+	return c.MaxBlobSize(ctx)
+}
+
+// needsChunking returns true if the encoded blob must be submitted as chunks and a manifest.
+//
+// Blobs starting with the manifest or chunk magic bytes are always chunked, so they can't be
+// mistaken for a manifest or a chunk on retrieval.
+func needsChunking(data []byte, chunkSize uint64) bool {
+	return uint64(len(data)) > chunkSize || bytes.HasPrefix(data, manifestMagic) || bytes.HasPrefix(data, chunkMagic)
+}
+
+// submitChunks submits encoded blobs that need chunking as ordered chunks, one transaction per chunk,
+// and replaces them with their manifests. Other blobs are returned unchanged. The receipts of the
+// chunk transactions are appended to result, and their IDs recorded in progress. Chunks already
+// recorded in progress aren't submitted again, and while progress is ambiguous the next chunk is
+// looked up before submitting it.
+func (c *CelestiaDA) submitChunks(ctx context.Context, data [][]byte, ns share.Namespace, gasPrice float64, shares bool, result *SubmitResult, progress *SubmitProgress) ([][]byte, error) {
+	chunkSize, err := c.maxChunkSize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]byte, len(data))
+	for i, d := range data {
+		if !needsChunking(d, chunkSize) {
+			out[i] = d
+			continue
+		}
+		if len(d) > maxDecodedBlobSize {
+			return nil, fmt.Errorf("%w: encoded blob %d has %d bytes, the maximum is %d", ErrBlobTooLarge, i, len(d), maxDecodedBlobSize)
+		}
+		payloadSize := int(chunkSize) - chunkHeaderSize
+		ids := progress.Chunks[i]
+		for index := len(ids); index*payloadSize < len(d); index++ {
+			end := min((index+1)*payloadSize, len(d))
+			chunk := make([]byte, chunkHeaderSize, chunkHeaderSize+end-index*payloadSize)
+			copy(chunk, chunkMagic)
+			chunk[len(chunkMagic)] = chunkVersion
+			binary.BigEndian.PutUint32(chunk[len(chunkMagic)+1:], uint32(index))
+			chunk = append(chunk, d[index*payloadSize:end]...)
+
+			b, err := blob.NewBlobV0(ns, chunk)
+			if err != nil {
+				return nil, err
+			}
+			if progress.Ambiguous {
+				id, ok, err := c.lookupSubmitted(ctx, b, ns, progress.Height)
+				if err != nil {
+					return nil, fmt.Errorf("failed to look up chunk %d of blob %d: %w", index, i, err)
+				}
+				if ok {
+					ids = append(ids, id)
+					progress.Chunks[i] = ids
+					continue
+				}
+				progress.Ambiguous = false
+			}
+			receipt, err := c.submitBlobs(ctx, []*blob.Blob{b}, ns, gasPrice, shares)
+			if err != nil {
+				return nil, fmt.Errorf("failed to submit chunk %d of blob %d: %w", index, i, err)
+			}
+			result.Receipts = append(result.Receipts, receipt)
+			ids = append(ids, receipt.Blobs[0].ID)
+			progress.Chunks[i] = ids
+		}
+		log.Println("successfully submitted blob chunks", "blob", i, "chunks", len(ids), "size", len(d))
+		out[i] = encodeManifest(d, ids)
+	}
+	return out, nil
+}
+
+// encodeManifest returns the manifest of a blob submitted as the chunks with the given IDs.
+func encodeManifest(data []byte, ids []da.ID) []byte {
+	sum := sha256.Sum256(data)
+	out := append([]byte{}, manifestMagic...)
+	out = append(out, manifestVersion)
+	out = binary.AppendUvarint(out, uint64(len(data)))
+	out = append(out, sum[:]...)
+	out = binary.AppendUvarint(out, uint64(len(ids)))
+	for _, id := range ids {
+		out = binary.AppendUvarint(out, uint64(len(id)))
+		out = append(out, id...)
+	}
+	return out
+}
+
+// manifest describes a blob submitted as chunks.
+type manifest struct {
+	size uint64
+	sum  []byte
+	ids  []da.ID
+}
+
+// decodeManifest parses a manifest created by encodeManifest.
+func decodeManifest(data []byte) (*manifest, error) {
+	r := data[len(manifestMagic):]
+	if len(r) < 1 {
+		return nil, fmt.Errorf("%w: truncated manifest", ErrInvalidBlobHeader)
+	}
+	if r[0] != manifestVersion {
+		return nil, fmt.Errorf("%w: unsupported manifest version %d", ErrInvalidBlobHeader, r[0])
+	}
+	r = r[1:]
+	size, n := binary.Uvarint(r)
+	if n <= 0 || size > maxDecodedBlobSize {
+		return nil, fmt.Errorf("%w: invalid manifest size", ErrInvalidBlobHeader)
+	}
+	r = r[n:]
+	if len(r) < sha256.Size {
+		return nil, fmt.Errorf("%w: truncated manifest", ErrInvalidBlobHeader)
+	}
+	m := &manifest{size: size, sum: r[:sha256.Size]}
+	r = r[sha256.Size:]
+	count, n := binary.Uvarint(r)
+	if n <= 0 || count == 0 || count > size {
+		return nil, fmt.Errorf("%w: invalid manifest chunk count", ErrInvalidBlobHeader)
+	}
+	r = r[n:]
+	for i := uint64(0); i < count; i++ {
+		l, n := binary.Uvarint(r)
+		if n <= 0 || l <= heightLen || l > uint64(len(r)-n) {
+			return nil, fmt.Errorf("%w: invalid manifest chunk ID", ErrInvalidBlobHeader)
+		}
+		m.ids = append(m.ids, r[n:n+int(l)])
+		r = r[n+int(l):]
+	}
+	if len(r) != 0 {
+		return nil, fmt.Errorf("%w: trailing manifest data", ErrInvalidBlobHeader)
+	}
+	return m, nil
+}
+
+// reassemble retrieves the chunks of a manifest and verifies the reassembled blob.
+func (c *CelestiaDA) reassemble(ctx context.Context, ns share.Namespace, data []byte) ([]byte, error) {
+	m, err := decodeManifest(data)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, m.size)
+	for index, id := range m.ids {
+		height, commitment := splitID(id)
+		chunk, err := c.getBlob(ctx, height, ns, commitment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get chunk %d: %w", index, err)
+		}
+		if !bytes.HasPrefix(chunk, chunkMagic) || len(chunk) < chunkHeaderSize || chunk[len(chunkMagic)] != chunkVersion {
+			return nil, fmt.Errorf("%w: invalid chunk %d", ErrChunkIntegrity, index)
+		}
+		if binary.BigEndian.Uint32(chunk[len(chunkMagic)+1:]) != uint32(index) {
+			return nil, fmt.Errorf("%w: chunk %d out of order", ErrChunkIntegrity, index)
+		}
+		if uint64(len(out)+len(chunk)-chunkHeaderSize) > m.size {
+			return nil, fmt.Errorf("%w: size exceeds manifest", ErrChunkIntegrity)
+		}
+		out = append(out, chunk[chunkHeaderSize:]...)
+	}
+	if sum := sha256.Sum256(out); uint64(len(out)) != m.size || !bytes.Equal(sum[:], m.sum) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrChunkIntegrity)
+	}
+	return out, nil
+}
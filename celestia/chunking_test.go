@@ -0,0 +1,124 @@
+package celestia
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifest(t *testing.T) {
+	data := []byte("chunked blob")
+	ids := []ID{makeID(1, bytes.Repeat([]byte{1}, 32)), makeID(2, bytes.Repeat([]byte{2}, 32))}
+	encoded := encodeManifest(data, ids)
+
+	m, err := decodeManifest(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(len(data)), m.size)
+	assert.Equal(t, ids, m.ids)
+
+	_, err = decodeManifest(encoded[:len(encoded)-1])
+	assert.ErrorIs(t, err, ErrInvalidBlobHeader)
+	_, err = decodeManifest(append(append([]byte{}, encoded...), 0))
+	assert.ErrorIs(t, err, ErrInvalidBlobHeader)
+	_, err = decodeManifest(manifestMagic)
+	assert.ErrorIs(t, err, ErrInvalidBlobHeader)
+}
+
+func TestCelestiaDA_Chunking(t *testing.T) {
+	m := setup(t)
+	defer teardown(m)
+	ctx := context.TODO()
+
+	chunked, err := NewCelestiaDAWithOptions(m.client, m.namespace, -1, ctx, WithChunking(minChunkSize))
+	assert.NoError(t, err)
+
+	large := make([]byte, 5*minChunkSize/2)
+	_, err = rand.Read(large)
+	assert.NoError(t, err)
+	small := []byte("small blob")
+	magic := append(append([]byte{}, manifestMagic...), []byte("raw blob starting with the magic bytes")...)
+
+	ids, err := chunked.Submit(ctx, []Blob{small, large, magic}, -1, nil)
+	assert.NoError(t, err)
+	assert.Len(t, ids, 3)
+
+	blobs, err := chunked.Get(ctx, ids, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []Blob{small, large, magic}, blobs)
+
+	t.Run("GetIDs", func(t *testing.T) {
+		// chunks are submitted first, the last height holds the small blob and the manifests
+		height, _ := splitID(ids[0])
+		chunkIDs, err := chunked.GetIDs(ctx, height-1, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, chunkIDs)
+		allIDs, err := chunked.GetIDs(ctx, height, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, ids, allIDs)
+
+		// without chunking the chunk is returned as is
+		chunkIDs, err = m.GetIDs(ctx, height-1, nil)
+		assert.NoError(t, err)
+		assert.Len(t, chunkIDs, 1)
+		_, err = chunked.Get(ctx, chunkIDs, nil)
+		assert.ErrorIs(t, err, ErrBlobIsChunk)
+	})
+
+	t.Run("Commit", func(t *testing.T) {
+		_, err := chunked.Commit(ctx, []Blob{large}, nil)
+		assert.ErrorIs(t, err, ErrChunkedCommitment)
+		commitments, err := chunked.Commit(ctx, []Blob{small}, nil)
+		assert.NoError(t, err)
+		assert.Len(t, commitments, 1)
+	})
+
+	t.Run("integrity", func(t *testing.T) {
+		manifest, err := m.Get(ctx, ids[1:2], nil)
+		assert.NoError(t, err)
+		decoded, err := decodeManifest(manifest[0])
+		assert.NoError(t, err)
+		assert.Len(t, decoded.ids, 3)
+
+		// swap the order of the chunks
+		swapped := encodeManifest(large, []ID{decoded.ids[1], decoded.ids[0], decoded.ids[2]})
+		_, err = chunked.reassemble(ctx, m.namespace, swapped)
+		assert.ErrorIs(t, err, ErrChunkIntegrity)
+
+		// checksum of different data
+		other := append([]byte{}, large...)
+		other[0] ^= 0xff
+		_, err = chunked.reassemble(ctx, m.namespace, encodeManifest(other, decoded.ids))
+		assert.ErrorIs(t, err, ErrChunkIntegrity)
+	})
+
+	t.Run("Ambiguous", func(t *testing.T) {
+		// the transaction of the second chunk is included, but fails, and without the submission
+		// index the chunk is found by scanning the namespace
+		data := make([]byte, 5*minChunkSize/2)
+		_, err := rand.Read(data)
+		require.NoError(t, err)
+		start := m.s.blob.currentHeight()
+		m.s.state.failSubmit(submitFailure{}, submitFailure{err: errors.New("timed out"), included: true})
+		_, err = chunked.Submit(ctx, []Blob{data}, -1, nil)
+		var submitErr *SubmitError
+		require.ErrorAs(t, err, &submitErr)
+		assert.True(t, submitErr.Progress.Ambiguous)
+		assert.Len(t, submitErr.Progress.Chunks[0], 1)
+
+		ids, err := chunked.ResumeSubmit(ctx, []Blob{data}, -1, nil, submitErr.Progress)
+		require.NoError(t, err)
+		// the included chunk isn't submitted again, only the last chunk and the manifest are
+		assert.Equal(t, start+4, m.s.blob.currentHeight())
+		blobs, err := chunked.Get(ctx, ids, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []Blob{data}, blobs)
+	})
+
+	_, err = NewCelestiaDAWithOptions(m.client, m.namespace, -1, ctx, WithChunking(10))
+	assert.Error(t, err)
+}
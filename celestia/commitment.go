@@ -0,0 +1,151 @@
+package celestia
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/celestiaorg/celestia-node/share"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rollkit/go-da"
+)
+
+// ErrInvalidScanDepth is returned by GetByCommitment for scan depths above the maximum.
+var ErrInvalidScanDepth = errors.New("invalid scan depth")
+
+const (
+	// defaultCommitmentScanDepth is the number of heights below the head scanned by GetByCommitment
+	// if no depth is given.
+	defaultCommitmentScanDepth = 100
+	// maxCommitmentScanDepth bounds the number of heights scanned by GetByCommitment.
+	maxCommitmentScanDepth = 10000
+)
+
+// CommitmentResult holds a blob found by its commitment.
+type CommitmentResult struct {
+	ID    da.ID    `json:"id"`
+	Blob  da.Blob  `json:"blob"`
+	Proof da.Proof `json:"proof"`
+}
+
+// GetByCommitment returns the ID, blob and inclusion proof of the blob in the namespace with the
+// given commitment, without knowing its height.
+//
+// The height is resolved through the submission index if the blob was submitted by this instance
+// with WithIndex, and otherwise by scanning the blobs at up to scanDepth heights below the local
+// head of the node, or 100 if scanDepth is 0. If the blob was included more than once, the highest
+// height is returned. ErrBlobNotFound is returned if the blob isn't found.
+func (c *CelestiaDA) GetByCommitment(ctx context.Context, commitment da.Commitment, ns da.Namespace, scanDepth uint64) (*CommitmentResult, error) {
+	namespace, err := c.defaultNamespace(ns)
+	if err != nil {
+		return nil, err
+	}
+	if scanDepth == 0 {
+		scanDepth = defaultCommitmentScanDepth
+	}
+	if scanDepth > maxCommitmentScanDepth {
+		return nil, fmt.Errorf("%w: %d exceeds the maximum of %d", ErrInvalidScanDepth, scanDepth, maxCommitmentScanDepth)
+	}
+
+	height, ok, err := c.indexedHeight(ctx, commitment, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if height, ok, err = c.scanCommitment(ctx, commitment, namespace, scanDepth); err != nil {
+			return nil, err
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: commitment %X", ErrBlobNotFound, commitment)
+	}
+
+	id := makeID(height, commitment)
+	blobs, err := c.Get(ctx, []da.ID{id}, namespace)
+	if err != nil {
+		return nil, err
+	}
+	proofs, err := c.GetProofs(ctx, []da.ID{id}, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return &CommitmentResult{ID: id, Blob: blobs[0], Proof: proofs[0]}, nil
+}
+
+// indexedHeight returns the highest height the blob was submitted at according to the submission
+// index, if enabled.
+func (c *CelestiaDA) indexedHeight(ctx context.Context, commitment da.Commitment, ns share.Namespace) (uint64, bool, error) {
+	if c.index == nil {
+		return 0, false, nil
+	}
+	entries, err := c.LookupSubmission(commitment)
+	if err != nil {
+		return 0, false, err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if bytes.Equal(entries[i].Namespace, ns) {
+			return entries[i].Height, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// scanCommitment looks for the commitment in the namespace at up to depth heights below the local
+// head, scanning rangeConcurrency heights concurrently, starting at the head. The scan ends early at
+// pruned heights.
+func (c *CelestiaDA) scanCommitment(ctx context.Context, commitment da.Commitment, ns share.Namespace, depth uint64) (uint64, bool, error) {
+	head, err := c.client.Header.LocalHead(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	top := head.Height()
+	bottom := uint64(1)
+	if top > depth {
+		bottom = top - depth + 1
+	}
+	for high := top; high >= bottom && high > 0; {
+		low := bottom
+		if high-bottom >= rangeConcurrency {
+			low = high - rangeConcurrency + 1
+		}
+		found := make([]bool, high-low+1)
+		pruned := make([]bool, high-low+1)
+		g, gctx := errgroup.WithContext(ctx)
+		for height := low; height <= high; height++ {
+			height := height
+			g.Go(func() error {
+				// chunks are included, so the chunks of a failed submission are found
+				blobs, err := c.getAllWithChunks(gctx, height, ns)
+				if errors.Is(err, ErrHeightPruned) {
+					pruned[height-low] = true
+					return nil
+				}
+				if err != nil {
+					return fmt.Errorf("height %d: %w", height, err)
+				}
+				for _, b := range blobs {
+					if bytes.Equal(b.commitment, commitment) {
+						found[height-low] = true
+					}
+				}
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return 0, false, err
+		}
+		for i := len(found) - 1; i >= 0; i-- {
+			if found[i] {
+				return low + uint64(i), true, nil
+			}
+			if pruned[i] {
+				// lower heights are pruned as well
+				return 0, false, nil
+			}
+		}
+		high = low - 1
+	}
+	return 0, false, nil
+}
@@ -0,0 +1,68 @@
+package celestia
+
+import (
+	"context"
+	"testing"
+
+	rpc "github.com/celestiaorg/celestia-node/api/rpc/client"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCelestiaDA_GetByCommitment(t *testing.T) {
+	m := setup(t)
+	defer teardown(m)
+	ctx := context.TODO()
+
+	var ids []ID
+	for i := 0; i < 20; i++ {
+		submitted, err := m.Submit(ctx, []Blob{[]byte{byte(i)}}, -1, nil)
+		require.NoError(t, err)
+		ids = append(ids, submitted...)
+	}
+
+	t.Run("Scan", func(t *testing.T) {
+		_, commitment := splitID(ids[2])
+		result, err := m.GetByCommitment(ctx, commitment, nil, 0)
+		require.NoError(t, err)
+		assert.Equal(t, ids[2], result.ID)
+		assert.Equal(t, Blob{2}, result.Blob)
+		assert.NotEmpty(t, result.Proof)
+
+		// height 3 is below the scanned heights
+		_, err = m.GetByCommitment(ctx, commitment, nil, 10)
+		assert.ErrorIs(t, err, ErrBlobNotFound)
+		_, err = m.GetByCommitment(ctx, []byte("unknown"), nil, 0)
+		assert.ErrorIs(t, err, ErrBlobNotFound)
+	})
+
+	t.Run("Pruned", func(t *testing.T) {
+		_, commitment := splitID(ids[2])
+		m.s.blob.fail(10, share.ErrOutsideSamplingWindow)
+		defer m.s.blob.fail(10, nil)
+		_, err := m.GetByCommitment(ctx, commitment, nil, 0)
+		assert.ErrorIs(t, err, ErrBlobNotFound)
+	})
+
+	t.Run("Index", func(t *testing.T) {
+		client, err := rpc.NewClient(ctx, m.s.server.URL, "test")
+		require.NoError(t, err)
+		defer client.Close()
+		indexed, err := NewCelestiaDAWithOptions(client, m.namespace, -1, ctx, WithIndex(t.TempDir()))
+		require.NoError(t, err)
+		defer indexed.Close()
+
+		submitted, err := indexed.Submit(ctx, []Blob{[]byte("indexed")}, -1, nil)
+		require.NoError(t, err)
+		for i := 0; i < 5; i++ {
+			_, err := m.Submit(ctx, []Blob{[]byte{byte(i)}}, -1, nil)
+			require.NoError(t, err)
+		}
+		_, commitment := splitID(submitted[0])
+		result, err := indexed.GetByCommitment(ctx, commitment, nil, 1)
+		require.NoError(t, err)
+		assert.Equal(t, submitted[0], result.ID)
+		assert.Equal(t, Blob("indexed"), result.Blob)
+	})
+}
@@ -0,0 +1,207 @@
+package celestia
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/klauspost/compress/zstd"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Compression is the algorithm used to compress blobs before submission.
+type Compression uint8
+
+const (
+	// CompressionNone disables compression.
+	CompressionNone Compression = iota
+	// CompressionGzip compresses blobs with gzip.
+	CompressionGzip
+	// CompressionZstd compresses blobs with zstd.
+	CompressionZstd
+)
+
+// String returns the name of the compression algorithm.
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionGzip:
+		return "gzip"
+	case CompressionZstd:
+		return "zstd"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(c))
+	}
+}
+
+// ParseCompression returns the compression algorithm with the given name.
+func ParseCompression(s string) (Compression, error) {
+	for _, c := range []Compression{CompressionNone, CompressionGzip, CompressionZstd} {
+		if c.String() == s {
+			return c, nil
+		}
+	}
+	return CompressionNone, fmt.Errorf("unknown compression algorithm %q, must be \"none\", \"gzip\" or \"zstd\"", s)
+}
+
+// ErrInvalidBlobHeader is returned when a blob with a celestia-da header can't be decoded.
+var ErrInvalidBlobHeader = errors.New("invalid blob header")
+
+// compressionMagic prefixes compressed blobs.
+//
+// Blobs without the prefix are returned as they are, so blobs submitted without compression, or
+// before compression was enabled, can still be read.
+var compressionMagic = []byte{0x00, 'c', 'd', 'z'}
+
+// compressionVersion is the version of the compression header:
+//
+//	magic (4 bytes) | version (1 byte) | algorithm (1 byte) | uncompressed size (uvarint) | payload
+const compressionVersion = 1
+
+// maxDecodedBlobSize bounds the declared uncompressed size to protect against decompression bombs.
+// Larger blobs are refused on submission, as they couldn't be read back.
+const maxDecodedBlobSize = 64 << 20
+
+// ErrBlobTooLarge is returned for blobs larger than the maximum decoded blob size of 64 MiB, and by
+// Submit for blobs larger than the maximum blob size without chunking.
+var ErrBlobTooLarge = errors.New("blob too large")
+
+// zstdDecoder returns the decoder of zstd blobs, created on first use. It is safe for concurrent use
+// with DecodeAll, and used independently of the configured algorithm so that blobs compressed with
+// zstd can always be read.
+var zstdDecoder = sync.OnceValues(func() (*zstd.Decoder, error) {
+	return zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxDecodedBlobSize))
+})
+
+// compressor is a blobTransform compressing blobs with a self-describing header.
+type compressor struct {
+	algorithm Compression
+	zstd      *zstd.Encoder
+}
+
+func newCompressor(algorithm Compression) (*compressor, error) {
+	c := &compressor{algorithm: algorithm}
+	switch algorithm {
+	case CompressionNone, CompressionGzip:
+	case CompressionZstd:
+		enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBetterCompression), zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		c.zstd = enc
+	default:
+		return nil, fmt.Errorf("unknown compression algorithm %d", algorithm)
+	}
+	return c, nil
+}
+
+func (c *compressor) encode(_ share.Namespace, data []byte) ([]byte, error) {
+	algorithm := c.algorithm
+	var payload []byte
+	switch algorithm {
+	case CompressionGzip:
+		var buf bytes.Buffer
+		w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		payload = buf.Bytes()
+	case CompressionZstd:
+		payload = c.zstd.EncodeAll(data, nil)
+	}
+
+	if algorithm == CompressionNone || len(payload)+compressionHeaderSize(len(data)) >= len(data) {
+		// not worth compressing, but a raw blob starting with the magic bytes must be wrapped to
+		// be read back correctly
+		if !bytes.HasPrefix(data, compressionMagic) {
+			return data, nil
+		}
+		algorithm, payload = CompressionNone, data
+	}
+
+	out := make([]byte, 0, compressionHeaderSize(len(data))+len(payload))
+	out = append(out, compressionMagic...)
+	out = append(out, compressionVersion, byte(algorithm))
+	out = binary.AppendUvarint(out, uint64(len(data)))
+	out = append(out, payload...)
+
+	attrs := metric.WithAttributes(attribute.String("algorithm", algorithm.String()))
+	daMetrics.uncompressedBytes.Add(context.Background(), int64(len(data)), attrs)
+	daMetrics.compressedBytes.Add(context.Background(), int64(len(out)), attrs)
+	daMetrics.compressionRatio.Record(context.Background(), float64(len(out))/float64(len(data)), attrs)
+	return out, nil
+}
+
+func (c *compressor) decode(_ share.Namespace, data []byte) ([]byte, error) {
+	return decompress(data)
+}
+
+// decompress reverses compressor.encode for any algorithm, returning blobs without a header as they are.
+func decompress(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, compressionMagic) {
+		return data, nil
+	}
+	header := data[len(compressionMagic):]
+	if len(header) < 2 {
+		return nil, fmt.Errorf("%w: truncated compression header", ErrInvalidBlobHeader)
+	}
+	if header[0] != compressionVersion {
+		return nil, fmt.Errorf("%w: unsupported compression header version %d", ErrInvalidBlobHeader, header[0])
+	}
+	algorithm := Compression(header[1])
+	size, n := binary.Uvarint(header[2:])
+	if n <= 0 || size > maxDecodedBlobSize {
+		return nil, fmt.Errorf("%w: invalid uncompressed size", ErrInvalidBlobHeader)
+	}
+	payload := header[2+n:]
+
+	var out []byte
+	switch algorithm {
+	case CompressionNone:
+		out = payload
+	case CompressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBlobHeader, err)
+		}
+		out, err = io.ReadAll(io.LimitReader(r, int64(size)+1))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBlobHeader, err)
+		}
+	case CompressionZstd:
+		dec, err := zstdDecoder()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd decoder: %w", err)
+		}
+		out, err = dec.DecodeAll(payload, make([]byte, 0, size))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBlobHeader, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown compression algorithm %d", ErrInvalidBlobHeader, algorithm)
+	}
+	if uint64(len(out)) != size {
+		return nil, fmt.Errorf("%w: uncompressed size mismatch", ErrInvalidBlobHeader)
+	}
+	return out, nil
+}
+
+// compressionHeaderSize returns the size of the compression header for a blob of the given size.
+func compressionHeaderSize(size int) int {
+	var buf [binary.MaxVarintLen64]byte
+	return len(compressionMagic) + 2 + binary.PutUvarint(buf[:], uint64(size))
+}
@@ -0,0 +1,120 @@
+package celestia
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompression(t *testing.T) {
+	compressible := bytes.Repeat([]byte("rollup block data "), 1000)
+	random := make([]byte, 1000)
+	_, err := rand.Read(random)
+	assert.NoError(t, err)
+	magic := append(append([]byte{}, compressionMagic...), []byte("raw blob starting with the magic bytes")...)
+
+	for _, algorithm := range []Compression{CompressionNone, CompressionGzip, CompressionZstd} {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			c, err := newCompressor(algorithm)
+			assert.NoError(t, err)
+
+			encoded, err := c.encode(nil, compressible)
+			assert.NoError(t, err)
+			if algorithm == CompressionNone {
+				assert.Equal(t, compressible, encoded)
+			} else {
+				assert.Less(t, len(encoded), len(compressible))
+			}
+			decoded, err := c.decode(nil, encoded)
+			assert.NoError(t, err)
+			assert.Equal(t, compressible, decoded)
+
+			// incompressible data is submitted as is
+			encoded, err = c.encode(nil, random)
+			assert.NoError(t, err)
+			assert.Equal(t, random, encoded)
+
+			// raw data that looks like a header is always wrapped
+			encoded, err = c.encode(nil, magic)
+			assert.NoError(t, err)
+			assert.NotEqual(t, magic, encoded)
+			decoded, err = c.decode(nil, encoded)
+			assert.NoError(t, err)
+			assert.Equal(t, magic, decoded)
+		})
+	}
+
+	t.Run("legacy", func(t *testing.T) {
+		decoded, err := decompress([]byte("uncompressed legacy blob"))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("uncompressed legacy blob"), decoded)
+	})
+
+	t.Run("corrupt", func(t *testing.T) {
+		c, err := newCompressor(CompressionZstd)
+		assert.NoError(t, err)
+		encoded, err := c.encode(nil, compressible)
+		assert.NoError(t, err)
+
+		_, err = decompress(encoded[:len(compressionMagic)+1])
+		assert.ErrorIs(t, err, ErrInvalidBlobHeader)
+		_, err = decompress(encoded[:len(encoded)-4])
+		assert.ErrorIs(t, err, ErrInvalidBlobHeader)
+
+		unknown := append([]byte{}, encoded...)
+		unknown[len(compressionMagic)+1] = 42
+		_, err = decompress(unknown)
+		assert.ErrorIs(t, err, ErrInvalidBlobHeader)
+	})
+
+	t.Run("parse", func(t *testing.T) {
+		c, err := ParseCompression("zstd")
+		assert.NoError(t, err)
+		assert.Equal(t, CompressionZstd, c)
+		_, err = ParseCompression("lz4")
+		assert.Error(t, err)
+	})
+}
+
+func TestCelestiaDA_Compression(t *testing.T) {
+	m := setup(t)
+	defer teardown(m)
+	ctx := context.TODO()
+
+	compressed, err := NewCelestiaDAWithOptions(m.client, m.namespace, -1, ctx, WithCompression(CompressionZstd))
+	assert.NoError(t, err)
+
+	data := bytes.Repeat([]byte{0x01, 0x02}, 1000)
+	plain, err := m.Commit(ctx, []Blob{data}, nil)
+	assert.NoError(t, err)
+	commitments, err := compressed.Commit(ctx, []Blob{data}, nil)
+	assert.NoError(t, err)
+	assert.NotEqual(t, plain, commitments)
+
+	// commitments must match the data that is actually submitted
+	encoded, err := compressed.encode(m.namespace, data)
+	assert.NoError(t, err)
+	expected, err := m.Commit(ctx, []Blob{encoded}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, commitments)
+
+	// the mock returns a legacy blob, which is passed through
+	blobs, err := compressed.Get(ctx, []ID{makeID(42, commitments[0])}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "This is an example of some blob data", string(blobs[0]))
+
+	// blobs that compress well below the max blob size are refused if they couldn't be decoded
+	height := m.s.blob.currentHeight()
+	large := make([]byte, maxDecodedBlobSize+1)
+	_, err = compressed.Submit(ctx, []Blob{large}, -1, nil)
+	assert.ErrorIs(t, err, ErrBlobTooLarge)
+	_, err = compressed.Commit(ctx, []Blob{large}, nil)
+	assert.ErrorIs(t, err, ErrBlobTooLarge)
+	assert.Equal(t, height, m.s.blob.currentHeight())
+
+	_, err = NewCelestiaDAWithOptions(m.client, m.namespace, -1, ctx, WithCompression(Compression(42)))
+	assert.Error(t, err)
+}
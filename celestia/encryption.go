@@ -0,0 +1,197 @@
+package celestia
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/celestiaorg/celestia-node/share"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+var (
+	// ErrUnknownEncryptionKey is returned when a blob was encrypted with a key that isn't in the keyring.
+	ErrUnknownEncryptionKey = errors.New("unknown encryption key")
+	// ErrDecryptionFailed is returned when a blob fails authentication, e.g. because it was tampered with.
+	ErrDecryptionFailed = errors.New("blob decryption failed")
+	// ErrBlobNotEncrypted is returned when a blob in a namespace with encryption keys isn't encrypted.
+	ErrBlobNotEncrypted = errors.New("blob is not encrypted")
+)
+
+// encryptionMagic prefixes encrypted blobs.
+var encryptionMagic = []byte{0x00, 'c', 'd', 'e'}
+
+// encryptionVersion is the version of the encryption header:
+//
+//	magic (4 bytes) | version (1 byte) | key ID (4 bytes, big endian) | nonce (24 bytes) | ciphertext
+const encryptionVersion = 1
+
+const encryptionHeaderSize = 4 + 1 + 4 + chacha20poly1305.NonceSizeX
+
+// EncryptionKeySize is the size of blob encryption keys.
+const EncryptionKeySize = chacha20poly1305.KeySize
+
+// Keyring holds blob encryption keys by namespace and key ID.
+//
+// New blobs are encrypted with the key with the highest ID in their namespace, older keys are kept
+// to decrypt blobs submitted before a key rotation.
+type Keyring struct {
+	mu     sync.RWMutex
+	keys   map[string]map[uint32]*encryptionKey
+	active map[string]uint32
+}
+
+// encryptionKey holds the keys derived from a blob encryption key.
+type encryptionKey struct {
+	// aead encrypts blobs.
+	aead cipher.AEAD
+	// nonceKey derives synthetic nonces.
+	nonceKey []byte
+}
+
+// NewKeyring returns an empty Keyring.
+func NewKeyring() *Keyring {
+	return &Keyring{
+		keys:   make(map[string]map[uint32]*encryptionKey),
+		active: make(map[string]uint32),
+	}
+}
+
+// Add adds a key for the namespace.
+func (k *Keyring) Add(ns share.Namespace, id uint32, key []byte) error {
+	if len(key) != EncryptionKeySize {
+		return fmt.Errorf("invalid encryption key size %d, expected %d", len(key), EncryptionKeySize)
+	}
+	encKey, err := deriveEncryptionKey(key)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	nsKey := string(ns)
+	if k.keys[nsKey] == nil {
+		k.keys[nsKey] = make(map[uint32]*encryptionKey)
+	}
+	if _, ok := k.keys[nsKey][id]; ok {
+		return fmt.Errorf("duplicate encryption key %d for namespace %s", id, hex.EncodeToString(ns))
+	}
+	k.keys[nsKey][id] = encKey
+	if active, ok := k.active[nsKey]; !ok || id > active {
+		k.active[nsKey] = id
+	}
+	return nil
+}
+
+// activeKey returns the key to encrypt new blobs with, or false if the namespace isn't encrypted.
+func (k *Keyring) activeKey(ns share.Namespace) (uint32, *encryptionKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	id, ok := k.active[string(ns)]
+	if !ok {
+		return 0, nil, false
+	}
+	return id, k.keys[string(ns)][id], true
+}
+
+// key returns the key with the given ID.
+func (k *Keyring) key(ns share.Namespace, id uint32) (*encryptionKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	key, ok := k.keys[string(ns)][id]
+	return key, ok
+}
+
+// hasKeys returns true if the namespace has at least one key.
+func (k *Keyring) hasKeys(ns share.Namespace) bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return len(k.keys[string(ns)]) > 0
+}
+
+// deriveEncryptionKey derives independent keys for encryption and nonce generation.
+func deriveEncryptionKey(key []byte) (*encryptionKey, error) {
+	encKey := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, nil, []byte("celestia-da blob encryption")), encKey); err != nil {
+		return nil, err
+	}
+	nonceKey := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, nil, []byte("celestia-da blob nonce")), nonceKey); err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.NewX(encKey)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptionKey{aead: aead, nonceKey: nonceKey}, nil
+}
+
+// encryptor is a blobTransform encrypting blobs in namespaces with keys in the keyring.
+//
+// Nonces are derived from the namespace and the plaintext, so encrypting a blob is deterministic.
+// This is required for Commit to return the commitments of the blobs Submit would post, and only
+// reveals whether two blobs in a namespace are equal.
+type encryptor struct {
+	keyring *Keyring
+}
+
+func (e *encryptor) encode(ns share.Namespace, data []byte) ([]byte, error) {
+	id, key, ok := e.keyring.activeKey(ns)
+	if !ok {
+		return data, nil
+	}
+
+	mac := hmac.New(sha256.New, key.nonceKey)
+	mac.Write(ns)
+	mac.Write(data)
+	nonce := mac.Sum(nil)[:chacha20poly1305.NonceSizeX]
+
+	out := make([]byte, encryptionHeaderSize, encryptionHeaderSize+len(data)+chacha20poly1305.Overhead)
+	copy(out, encryptionMagic)
+	out[len(encryptionMagic)] = encryptionVersion
+	binary.BigEndian.PutUint32(out[len(encryptionMagic)+1:], id)
+	copy(out[encryptionHeaderSize-len(nonce):], nonce)
+	return key.aead.Seal(out, nonce, data, encryptionAD(ns, out[:encryptionHeaderSize])), nil
+}
+
+func (e *encryptor) decode(ns share.Namespace, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, encryptionMagic) {
+		if e.keyring.hasKeys(ns) {
+			return nil, fmt.Errorf("%w: namespace %s requires encrypted blobs", ErrBlobNotEncrypted, hex.EncodeToString(ns))
+		}
+		return data, nil
+	}
+	if len(data) < encryptionHeaderSize+chacha20poly1305.Overhead {
+		return nil, fmt.Errorf("%w: truncated encryption header", ErrInvalidBlobHeader)
+	}
+	if data[len(encryptionMagic)] != encryptionVersion {
+		return nil, fmt.Errorf("%w: unsupported encryption header version %d", ErrInvalidBlobHeader, data[len(encryptionMagic)])
+	}
+	id := binary.BigEndian.Uint32(data[len(encryptionMagic)+1:])
+	key, ok := e.keyring.key(ns, id)
+	if !ok {
+		return nil, fmt.Errorf("%w: key %d for namespace %s", ErrUnknownEncryptionKey, id, hex.EncodeToString(ns))
+	}
+	header := data[:encryptionHeaderSize]
+	nonce := header[encryptionHeaderSize-chacha20poly1305.NonceSizeX:]
+	plaintext, err := key.aead.Open(nil, nonce, data[encryptionHeaderSize:], encryptionAD(ns, header))
+	if err != nil {
+		return nil, fmt.Errorf("%w: key %d for namespace %s: %v", ErrDecryptionFailed, id, hex.EncodeToString(ns), err)
+	}
+	return plaintext, nil
+}
+
+// encryptionAD binds the ciphertext to its namespace and header.
+func encryptionAD(ns share.Namespace, header []byte) []byte {
+	ad := make([]byte, 0, len(ns)+len(header))
+	ad = append(ad, ns...)
+	return append(ad, header...)
+}
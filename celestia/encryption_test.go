@@ -0,0 +1,126 @@
+package celestia
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryption(t *testing.T) {
+	ns, err := ParseNamespace("0000c9761e8b221ae42f")
+	assert.NoError(t, err)
+	other, err := ParseNamespace("0000c9761e8b221ae430")
+	assert.NoError(t, err)
+	key1 := bytes.Repeat([]byte{1}, EncryptionKeySize)
+	key2 := bytes.Repeat([]byte{2}, EncryptionKeySize)
+
+	keyring := NewKeyring()
+	assert.NoError(t, keyring.Add(ns, 1, key1))
+	assert.Error(t, keyring.Add(ns, 1, key2))
+	assert.Error(t, keyring.Add(ns, 2, key2[:16]))
+	e := &encryptor{keyring: keyring}
+
+	data := []byte("private rollup data")
+	encrypted, err := e.encode(ns, data)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(encrypted), string(data))
+
+	t.Run("deterministic", func(t *testing.T) {
+		again, err := e.encode(ns, data)
+		assert.NoError(t, err)
+		assert.Equal(t, encrypted, again)
+	})
+
+	t.Run("roundtrip", func(t *testing.T) {
+		decrypted, err := e.decode(ns, encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, data, decrypted)
+	})
+
+	t.Run("unencrypted_namespace", func(t *testing.T) {
+		encoded, err := e.encode(other, data)
+		assert.NoError(t, err)
+		assert.Equal(t, data, encoded)
+		decoded, err := e.decode(other, data)
+		assert.NoError(t, err)
+		assert.Equal(t, data, decoded)
+	})
+
+	t.Run("not_encrypted", func(t *testing.T) {
+		_, err := e.decode(ns, data)
+		assert.ErrorIs(t, err, ErrBlobNotEncrypted)
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		tampered := append([]byte{}, encrypted...)
+		tampered[len(tampered)-1] ^= 0xff
+		_, err := e.decode(ns, tampered)
+		assert.ErrorIs(t, err, ErrDecryptionFailed)
+	})
+
+	t.Run("wrong_namespace", func(t *testing.T) {
+		assert.NoError(t, keyring.Add(other, 1, key1))
+		_, err := e.decode(other, encrypted)
+		assert.ErrorIs(t, err, ErrDecryptionFailed)
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		rotated := NewKeyring()
+		assert.NoError(t, rotated.Add(ns, 2, key2))
+		assert.NoError(t, rotated.Add(ns, 1, key1))
+		r := &encryptor{keyring: rotated}
+
+		// old blobs can still be decrypted
+		decrypted, err := r.decode(ns, encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, data, decrypted)
+
+		// new blobs use the newest key
+		encoded, err := r.encode(ns, data)
+		assert.NoError(t, err)
+		assert.NotEqual(t, encrypted, encoded)
+		_, err = e.decode(ns, encoded)
+		assert.ErrorIs(t, err, ErrUnknownEncryptionKey)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		_, err := e.decode(ns, encrypted[:encryptionHeaderSize])
+		assert.ErrorIs(t, err, ErrInvalidBlobHeader)
+	})
+}
+
+func TestCelestiaDA_Encryption(t *testing.T) {
+	m := setup(t)
+	defer teardown(m)
+	ctx := context.TODO()
+
+	keyring := NewKeyring()
+	assert.NoError(t, keyring.Add(m.namespace, 1, bytes.Repeat([]byte{1}, EncryptionKeySize)))
+	encrypted, err := NewCelestiaDAWithOptions(m.client, m.namespace, -1, ctx,
+		WithCompression(CompressionZstd), WithEncryption(keyring))
+	assert.NoError(t, err)
+
+	data := bytes.Repeat([]byte{0x01, 0x02}, 1000)
+	blob, err := encrypted.encode(m.namespace, data)
+	assert.NoError(t, err)
+	// compressed before encryption
+	assert.Less(t, len(blob), len(data))
+	decoded, err := encrypted.decode(m.namespace, blob)
+	assert.NoError(t, err)
+	assert.Equal(t, data, decoded)
+
+	ids, err := encrypted.Submit(ctx, []Blob{data}, -1, nil)
+	assert.NoError(t, err)
+	blobs, err := encrypted.Get(ctx, ids, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []Blob{data}, blobs)
+
+	// the mock returns a plaintext example blob for unknown IDs, which must be rejected
+	_, err = encrypted.Get(ctx, []ID{makeID(42, []byte("commitment"))}, nil)
+	assert.ErrorIs(t, err, ErrBlobNotEncrypted)
+
+	_, err = NewCelestiaDAWithOptions(m.client, m.namespace, -1, ctx, WithEncryption(nil))
+	assert.Error(t, err)
+}
@@ -0,0 +1,114 @@
+package celestia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/celestiaorg/celestia-app/x/blob/types"
+	"github.com/celestiaorg/celestia-node/blob"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/filecoin-project/go-jsonrpc"
+)
+
+var (
+	// ErrBlobNotFound is returned for IDs of blobs the node doesn't have.
+	ErrBlobNotFound = errors.New("blob not found")
+	// ErrHeightFromFuture is returned for heights beyond the head of the node.
+	ErrHeightFromFuture = errors.New("height is from the future")
+	// ErrHeightPruned is returned for heights whose data the node no longer stores.
+	ErrHeightPruned = errors.New("height is pruned")
+	// ErrBlobRejected is returned by Submit for blobs the node rejected as invalid, which fail the same
+	// way when submitted again.
+	ErrBlobRejected = errors.New("blob rejected by the node")
+)
+
+// Errors returned by celestia-node over JSON-RPC only keep their message, as celestia-node v0.13
+// doesn't register its errors with go-jsonrpc, so they can only be told apart by their message. All
+// matching of messages happens in this file, against the messages of the exported errors of
+// celestia-node, so an upgrade changing them is caught by TestNodeErrorMessages.
+
+// nodeErrors maps celestia-node errors to the errors of this package.
+var nodeErrors = []struct {
+	node error
+	err  error
+}{
+	{blob.ErrBlobNotFound, ErrBlobNotFound},
+	// returned by nodes with pruning enabled for heights outside of the sampling window
+	{share.ErrOutsideSamplingWindow, ErrHeightPruned},
+}
+
+// nodeRejections are the errors of the blob module of celestia-app that the node rejects
+// transactions with before broadcasting them, if their blobs are invalid.
+var nodeRejections = []error{
+	types.ErrReservedNamespace,
+	types.ErrInvalidNamespaceLen,
+	types.ErrParitySharesNamespace,
+	types.ErrTailPaddingNamespace,
+	types.ErrTxNamespace,
+	types.ErrInvalidNamespace,
+	types.ErrInvalidNamespaceVersion,
+	types.ErrUnsupportedShareVersion,
+	types.ErrZeroBlobSize,
+	types.ErrTotalBlobSizeTooLarge,
+}
+
+// errWSClosed has the message go-jsonrpc v0.3.1 fails requests with that were in flight when the
+// websocket connection to the node closed, which it doesn't export.
+var errWSClosed = errors.New("handler: websocket connection closed")
+
+// isNodeError reports whether err is, or wraps, the node error target: either err wraps target, or
+// its message is the message of target, or ends with it.
+func isNodeError(err, target error) bool {
+	if errors.Is(err, target) {
+		return true
+	}
+	var clientErr *jsonrpc.ErrClient
+	if errors.As(err, &clientErr) {
+		// the request failed before reaching the node
+		return false
+	}
+	msg, message := err.Error(), target.Error()
+	return msg == message || strings.HasSuffix(msg, ": "+message)
+}
+
+// isConnectionError reports whether err is caused by the connection to the node rather than by
+// the request.
+func isConnectionError(err error) bool {
+	var connErr *jsonrpc.RPCConnectionError
+	return errors.As(err, &connErr) || isNodeError(err, errWSClosed)
+}
+
+// rejectionError maps an error of a transaction the node rejected as invalid to ErrBlobRejected,
+// keeping the original error in the chain, and returns nil for other errors.
+func rejectionError(err error) error {
+	for _, rejection := range nodeRejections {
+		if isNodeError(err, rejection) {
+			return fmt.Errorf("%w: %w", ErrBlobRejected, err)
+		}
+	}
+	return nil
+}
+
+// heightError maps an error of a node request at height to ErrBlobNotFound, ErrHeightPruned or
+// ErrHeightFromFuture, keeping the original error in the chain. Other errors are returned as is.
+func (c *CelestiaDA) heightError(ctx context.Context, height uint64, err error) error {
+	if err == nil || errors.Is(err, ErrBlobNotFound) || errors.Is(err, ErrHeightPruned) || errors.Is(err, ErrHeightFromFuture) {
+		return err
+	}
+	for _, e := range nodeErrors {
+		if isNodeError(err, e.node) {
+			return fmt.Errorf("%w: %w", e.err, err)
+		}
+	}
+	if ctx.Err() != nil {
+		return err
+	}
+	// the node fails requests beyond its head with an error that carries no sentinel
+	head, headErr := c.client.Header.LocalHead(ctx)
+	if headErr == nil && height > head.Height() {
+		return fmt.Errorf("%w: height %d, head %d: %w", ErrHeightFromFuture, height, head.Height(), err)
+	}
+	return err
+}
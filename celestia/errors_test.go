@@ -0,0 +1,92 @@
+package celestia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/celestiaorg/celestia-node/blob"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/filecoin-project/go-jsonrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCelestiaDA_Errors(t *testing.T) {
+	m := setup(t)
+	defer teardown(m)
+	ctx := context.TODO()
+
+	ids, err := m.Submit(ctx, []Blob{[]byte("blob")}, -1, nil)
+	require.NoError(t, err)
+	height, _ := splitID(ids[0])
+
+	t.Run("BlobNotFound", func(t *testing.T) {
+		_, err := m.Get(ctx, []ID{makeID(height, []byte("unknown"))}, nil)
+		assert.ErrorIs(t, err, ErrBlobNotFound)
+
+		m.s.blob.fail(height+1, blob.ErrBlobNotFound)
+		ids, err := m.GetIDs(ctx, height+1, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+	})
+
+	t.Run("HeightFromFuture", func(t *testing.T) {
+		future := height + 100
+		m.s.blob.fail(future, errors.New("header: given height is from the future"))
+		_, err := m.GetIDs(ctx, future, nil)
+		assert.ErrorIs(t, err, ErrHeightFromFuture)
+		_, err = m.Get(ctx, []ID{makeID(future, []byte("commitment"))}, nil)
+		assert.ErrorIs(t, err, ErrHeightFromFuture)
+		_, err = m.GetProofs(ctx, []ID{makeID(future, []byte("commitment"))}, nil)
+		assert.ErrorIs(t, err, ErrHeightFromFuture)
+	})
+
+	t.Run("HeightPruned", func(t *testing.T) {
+		m.s.blob.fail(height, fmt.Errorf("getting shares: %w", share.ErrOutsideSamplingWindow))
+		defer m.s.blob.fail(height, nil)
+		_, err := m.GetIDs(ctx, height, nil)
+		assert.ErrorIs(t, err, ErrHeightPruned)
+		_, err = m.Get(ctx, ids, nil)
+		assert.ErrorIs(t, err, ErrHeightPruned)
+		_, err = m.GetAllRange(ctx, height, height, nil)
+		assert.ErrorIs(t, err, ErrHeightPruned)
+	})
+
+	t.Run("Other", func(t *testing.T) {
+		m.s.blob.fail(height, errors.New("internal error"))
+		defer m.s.blob.fail(height, nil)
+		_, err := m.GetIDs(ctx, height, nil)
+		require.Error(t, err)
+		for _, target := range []error{ErrBlobNotFound, ErrHeightFromFuture, ErrHeightPruned} {
+			assert.NotErrorIs(t, err, target)
+		}
+	})
+}
+
+func TestNodeErrorMessages(t *testing.T) {
+	// errors received over JSON-RPC only keep the message of the node error
+	for _, e := range nodeErrors {
+		t.Run(e.node.Error(), func(t *testing.T) {
+			received := errors.New(e.node.Error())
+			assert.True(t, isNodeError(received, e.node))
+			assert.True(t, isNodeError(fmt.Errorf("getting shares: %w", received), e.node))
+			assert.True(t, isNodeError(fmt.Errorf("getting shares: %w", e.node), e.node))
+			assert.False(t, isNodeError(errors.New(e.node.Error()+" at height 10"), e.node))
+			assert.False(t, isNodeError(errors.New("eds: "+e.node.Error()+": retry"), e.node))
+			assert.False(t, isNodeError(fmt.Errorf("%s: %w", e.node.Error(), &jsonrpc.ErrClient{}), e.node))
+		})
+	}
+
+	for _, rejection := range nodeRejections {
+		received := fmt.Errorf("broadcasting tx: %w", errors.New(rejection.Error()))
+		assert.ErrorIs(t, rejectionError(received), ErrBlobRejected)
+	}
+	assert.NoError(t, rejectionError(errors.New("insufficient funds")))
+
+	// go-jsonrpc v0.3.1 doesn't export the error of requests failed by a closed connection
+	assert.True(t, isConnectionError(&jsonrpc.RPCConnectionError{}))
+	assert.True(t, isConnectionError(errors.New("handler: websocket connection closed")))
+	assert.False(t, isConnectionError(blob.ErrBlobNotFound))
+}
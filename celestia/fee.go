@@ -0,0 +1,118 @@
+package celestia
+
+import (
+	"context"
+	"crypto/sha256"
+	"math"
+
+	"github.com/celestiaorg/celestia-app/pkg/appconsts"
+	"github.com/celestiaorg/celestia-app/pkg/shares"
+	"github.com/celestiaorg/celestia-app/x/blob/types"
+	"github.com/celestiaorg/celestia-node/blob"
+
+	"github.com/rollkit/go-da"
+)
+
+// FeeEstimate is the projected cost of submitting blobs.
+type FeeEstimate struct {
+	// Transactions is the number of transactions the blobs would be submitted in, including the
+	// transactions of chunks with WithChunking.
+	Transactions int `json:"transactions"`
+	// Shares is the number of shares the blobs would occupy in the data square.
+	Shares int `json:"shares"`
+	// Gas is the total gas limit of the transactions.
+	Gas uint64 `json:"gas"`
+	// GasPrice is the gas price configured for the instance, in utia/gas, and Fee the total fee at
+	// that price, in utia.
+	GasPrice float64 `json:"gas_price"`
+	Fee      uint64  `json:"fee"`
+	// SuggestedGasPrice is the default minimum gas price accepted by validators, in utia/gas, and
+	// SuggestedFee the total fee at that price, in utia.
+	SuggestedGasPrice float64 `json:"suggested_gas_price"`
+	SuggestedFee      uint64  `json:"suggested_fee"`
+}
+
+// estimateFee returns the gas limit and the fee in utia of a transaction including blobs of the
+// given sizes, estimated like the node does. A negative gas price uses the default minimum.
+func estimateFee(sizes []uint32, gasPrice float64) (gas, fee uint64) {
+	if gasPrice < 0 {
+		gasPrice = appconsts.DefaultMinGasPrice
+	}
+	gas = types.DefaultEstimateGas(sizes)
+	return gas, uint64(math.Ceil(gasPrice * float64(gas)))
+}
+
+// EstimateFee returns the projected gas and fees of submitting the blobs with Submit, at the gas
+// price configured for the instance and at the suggested gas price.
+//
+// Blobs are encoded with the configured blob transforms, and split into transactions and chunks
+// like Submit does, so the fees match the fees paid by Submit at the same gas prices.
+func (c *CelestiaDA) EstimateFee(ctx context.Context, daBlobs []da.Blob, ns da.Namespace) (*FeeEstimate, error) {
+	namespace, err := c.defaultNamespace(ns)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.encodeBlobs(daBlobs, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// sizes of the blobs of each transaction
+	var txs [][]uint32
+	if c.chunking {
+		chunkSize, err := c.maxChunkSize(ctx)
+		if err != nil {
+			return nil, err
+		}
+		payloadSize := int(chunkSize) - chunkHeaderSize
+		for i, d := range data {
+			if !needsChunking(d, chunkSize) {
+				continue
+			}
+			ids := make([]da.ID, 0, (len(d)+payloadSize-1)/payloadSize)
+			for index := 0; index*payloadSize < len(d); index++ {
+				end := min((index+1)*payloadSize, len(d))
+				txs = append(txs, []uint32{uint32(chunkHeaderSize + end - index*payloadSize)})
+				ids = append(ids, make(da.ID, heightLen+sha256.Size))
+			}
+			data[i] = encodeManifest(d, ids)
+		}
+	}
+	blobs := make([]*blob.Blob, len(data))
+	for i, d := range data {
+		if blobs[i], err = blob.NewBlobV0(namespace, d); err != nil {
+			return nil, err
+		}
+	}
+	maxSize, err := c.MaxBlobSize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, batch := range packBlobs(blobs, maxSize) {
+		sizes := make([]uint32, len(batch))
+		for i, b := range batch {
+			sizes[i] = uint32(len(b.Data))
+		}
+		txs = append(txs, sizes)
+	}
+
+	estimate := &FeeEstimate{
+		Transactions:      len(txs),
+		GasPrice:          c.GasPrice(),
+		SuggestedGasPrice: appconsts.DefaultMinGasPrice,
+	}
+	if estimate.GasPrice < 0 {
+		estimate.GasPrice = appconsts.DefaultMinGasPrice
+	}
+	for _, sizes := range txs {
+		for _, size := range sizes {
+			estimate.Shares += shares.SparseSharesNeeded(size)
+		}
+		gas, fee := estimateFee(sizes, estimate.GasPrice)
+		_, suggested := estimateFee(sizes, estimate.SuggestedGasPrice)
+		estimate.Gas += gas
+		estimate.Fee += fee
+		estimate.SuggestedFee += suggested
+	}
+	return estimate, nil
+}
@@ -0,0 +1,64 @@
+package celestia
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/celestiaorg/celestia-app/pkg/appconsts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCelestiaDA_EstimateFee(t *testing.T) {
+	m := setup(t)
+	defer teardown(m)
+	ctx := context.TODO()
+
+	blobs := []Blob{[]byte("first"), bytes.Repeat([]byte("x"), 600)}
+	estimate, err := m.EstimateFee(ctx, blobs, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, estimate.Transactions)
+	assert.Equal(t, 3, estimate.Shares)
+	assert.Equal(t, appconsts.DefaultMinGasPrice, estimate.GasPrice)
+	assert.Equal(t, appconsts.DefaultMinGasPrice, estimate.SuggestedGasPrice)
+	assert.Equal(t, estimate.Fee, estimate.SuggestedFee)
+
+	// the estimate matches the receipt of the submission
+	result, err := m.SubmitWithReceipts(ctx, blobs, -1, nil)
+	require.NoError(t, err)
+	require.Len(t, result.Receipts, 1)
+	assert.Equal(t, result.Receipts[0].GasWanted, estimate.Gas)
+	assert.Equal(t, result.Receipts[0].Fee, estimate.Fee)
+
+	t.Run("GasPrice", func(t *testing.T) {
+		priced := NewCelestiaDA(m.client, m.namespace, 0.1, ctx)
+		estimate, err := priced.EstimateFee(ctx, blobs, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 0.1, estimate.GasPrice)
+		assert.Greater(t, estimate.Fee, estimate.SuggestedFee)
+	})
+
+	t.Run("Chunks", func(t *testing.T) {
+		chunked, err := NewCelestiaDAWithOptions(m.client, m.namespace, -1, ctx, WithChunking(minChunkSize))
+		require.NoError(t, err)
+		blobs := []Blob{bytes.Repeat([]byte("y"), 2*minChunkSize)}
+		estimate, err := chunked.EstimateFee(ctx, blobs, nil)
+		require.NoError(t, err)
+		// three chunks and the manifest
+		assert.Equal(t, 4, estimate.Transactions)
+
+		result, err := chunked.SubmitWithReceipts(ctx, blobs, -1, nil)
+		require.NoError(t, err)
+		var gas, fee uint64
+		for _, receipt := range result.Receipts {
+			gas += receipt.GasWanted
+			fee += receipt.Fee
+		}
+		assert.Equal(t, gas, estimate.Gas)
+		assert.Equal(t, fee, estimate.Fee)
+	})
+
+	_, err = m.EstimateFee(ctx, blobs, make([]byte, 30))
+	assert.ErrorIs(t, err, ErrInvalidNamespace)
+}
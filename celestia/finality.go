@@ -0,0 +1,98 @@
+package celestia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/celestiaorg/celestia-node/share"
+
+	"github.com/rollkit/go-da"
+)
+
+// ErrNotFinal is returned by Submit with WithFinality if the submitted blobs weren't confirmed in
+// time. The blobs were included regardless, and their IDs are returned along with the error.
+var ErrNotFinal = errors.New("submitted blobs not confirmed")
+
+// finalityPollInterval is the delay between attempts to confirm submitted blobs.
+const finalityPollInterval = time.Second
+
+// FinalityConfig configures the confirmation of submitted blobs.
+type FinalityConfig struct {
+	// Timeout bounds the time to wait for the confirmation of all blobs passed to Submit.
+	Timeout time.Duration `json:"timeout"`
+	// Depth is the number of heights that have to follow the inclusion height before blobs are
+	// confirmed.
+	Depth uint64 `json:"depth"`
+}
+
+// DefaultFinalityConfig returns the default finality configuration.
+func DefaultFinalityConfig() FinalityConfig {
+	return FinalityConfig{Timeout: time.Minute}
+}
+
+// WithFinality makes Submit return only after the submitted blobs are confirmed: the node synced
+// the header at the inclusion height plus config.Depth, and every blob can be retrieved and its
+// inclusion proof validated. If that takes longer than config.Timeout, Submit returns the IDs of
+// the submitted blobs with ErrNotFinal.
+func WithFinality(config FinalityConfig) Option {
+	return func(c *CelestiaDA) error {
+		if config.Timeout <= 0 {
+			return fmt.Errorf("invalid finality timeout %s", config.Timeout)
+		}
+		c.finality = &config
+		return nil
+	}
+}
+
+// confirm waits until the blobs with the given IDs are confirmed, see WithFinality.
+func (c *CelestiaDA) confirm(ctx context.Context, ids []da.ID, ns share.Namespace) error {
+	ctx, cancel := context.WithTimeout(ctx, c.finality.Timeout)
+	defer cancel()
+
+	var last uint64
+	for _, id := range ids {
+		height, _ := splitID(id)
+		last = max(last, height)
+	}
+	if _, err := c.client.Header.WaitForHeight(ctx, last+c.finality.Depth); err != nil {
+		return fmt.Errorf("%w: waiting for height %d: %w", ErrNotFinal, last+c.finality.Depth, err)
+	}
+
+	for _, id := range ids {
+		for {
+			err := c.confirmBlob(ctx, id, ns)
+			if err == nil {
+				break
+			}
+			select {
+			case <-time.After(finalityPollInterval):
+			case <-ctx.Done():
+				height, _ := splitID(id)
+				return fmt.Errorf("%w: blob at height %d: %w", ErrNotFinal, height, err)
+			}
+		}
+	}
+	return nil
+}
+
+// confirmBlob checks that the node returns the blob, and validates its inclusion proof.
+func (c *CelestiaDA) confirmBlob(ctx context.Context, id da.ID, ns share.Namespace) error {
+	height, commitment := splitID(id)
+	if _, err := c.client.Blob.Get(ctx, height, ns, commitment); err != nil {
+		return err
+	}
+	proof, err := c.client.Blob.GetProof(ctx, height, ns, commitment)
+	if err != nil {
+		return err
+	}
+	included, err := c.client.Blob.Included(ctx, height, ns, proof, commitment)
+	if err != nil {
+		return err
+	}
+	if !included {
+		return errors.New("inclusion proof is invalid")
+	}
+	return nil
+}
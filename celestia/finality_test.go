@@ -0,0 +1,54 @@
+package celestia
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	rpc "github.com/celestiaorg/celestia-node/api/rpc/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCelestiaDA_Finality(t *testing.T) {
+	m := setup(t)
+	defer teardown(m)
+	ctx := context.TODO()
+
+	client, err := rpc.NewClient(ctx, m.s.server.URL, "test")
+	require.NoError(t, err)
+	defer client.Close()
+	final := func(config FinalityConfig) *CelestiaDA {
+		c, err := NewCelestiaDAWithOptions(client, m.namespace, -1, ctx, WithFinality(config))
+		require.NoError(t, err)
+		return c
+	}
+
+	t.Run("Confirmed", func(t *testing.T) {
+		ids, err := final(DefaultFinalityConfig()).Submit(ctx, []Blob{[]byte("blob")}, -1, nil)
+		require.NoError(t, err)
+		assert.Len(t, ids, 1)
+	})
+
+	t.Run("Depth", func(t *testing.T) {
+		// the height after the inclusion height is reached by another submission
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			_, _ = m.Submit(ctx, []Blob{[]byte("next")}, -1, nil)
+		}()
+		ids, err := final(FinalityConfig{Timeout: 10 * time.Second, Depth: 1}).Submit(ctx, []Blob{[]byte("blob")}, -1, nil)
+		require.NoError(t, err)
+		height, _ := splitID(ids[0])
+		assert.Greater(t, m.s.blob.currentHeight(), height)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		ids, err := final(FinalityConfig{Timeout: 100 * time.Millisecond, Depth: 10}).Submit(ctx, []Blob{[]byte("blob")}, -1, nil)
+		assert.ErrorIs(t, err, ErrNotFinal)
+		// the blob was included regardless
+		assert.Len(t, ids, 1)
+	})
+
+	_, err = NewCelestiaDAWithOptions(client, m.namespace, -1, ctx, WithFinality(FinalityConfig{}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,211 @@
+package celestia
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/celestiaorg/celestia-node/blob"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/rollkit/go-da"
+)
+
+// ErrIndexDisabled is returned by submission index queries if no index is configured.
+var ErrIndexDisabled = errors.New("submission index is disabled")
+
+const (
+	// defaultIndexLimit is the number of entries returned by ListSubmissions if no limit is given.
+	defaultIndexLimit = 100
+	// maxIndexLimit bounds the number of entries returned by ListSubmissions.
+	maxIndexLimit = 1000
+)
+
+// Index key prefixes. Entries are stored under both keys, so they can be found by commitment and
+// listed by namespace and height.
+const (
+	indexPrefixCommitment byte = 'c'
+	indexPrefixNamespace  byte = 'n'
+)
+
+// IndexEntry records a blob submitted by Submit.
+type IndexEntry struct {
+	Namespace  []byte `json:"namespace"`
+	Commitment []byte `json:"commitment"`
+	Height     uint64 `json:"height"`
+	// Size is the size of the blob as submitted, after the configured blob transforms.
+	Size int `json:"size"`
+	// TxSize is the total size of the blobs in the transaction that included the blob.
+	TxSize   int       `json:"tx_size"`
+	GasPrice float64   `json:"gas_price"`
+	Time     time.Time `json:"time"`
+	// Caller identifies the client that submitted the blob, see ContextWithCaller.
+	Caller string `json:"caller,omitempty"`
+	// TxHash, Fee and GasUsed are taken from the receipt of the transaction, see Receipt.
+	TxHash  string `json:"tx_hash,omitempty"`
+	Fee     uint64 `json:"fee,omitempty"`
+	GasUsed uint64 `json:"gas_used,omitempty"`
+	// Shares is only known for blobs submitted by SubmitWithReceipts.
+	Shares *ShareRange `json:"shares,omitempty"`
+}
+
+// ID returns the ID of the blob.
+func (e *IndexEntry) ID() da.ID {
+	return makeID(e.Height, e.Commitment)
+}
+
+type callerKey struct{}
+
+// ContextWithCaller returns a context that records caller as the submitter of blobs in the
+// submission index.
+func ContextWithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext returns the caller set by ContextWithCaller, if any.
+func CallerFromContext(ctx context.Context) string {
+	caller, _ := ctx.Value(callerKey{}).(string)
+	return caller
+}
+
+// WithIndex records every blob submitted by Submit, including the chunks of chunked blobs, in a
+// submission index stored in dir. The index is closed by Close.
+func WithIndex(dir string) Option {
+	return func(c *CelestiaDA) error {
+		db, err := leveldb.OpenFile(dir, nil)
+		if err != nil {
+			return fmt.Errorf("failed to open submission index: %w", err)
+		}
+		c.index = db
+		return nil
+	}
+}
+
+// indexKey returns the key of an entry under the given prefix.
+func indexKey(prefix byte, e *IndexEntry) []byte {
+	var key []byte
+	switch prefix {
+	case indexPrefixCommitment:
+		key = append([]byte{prefix}, e.Commitment...)
+		key = binary.BigEndian.AppendUint64(key, e.Height)
+	case indexPrefixNamespace:
+		key = append([]byte{prefix}, e.Namespace...)
+		key = binary.BigEndian.AppendUint64(key, e.Height)
+		key = append(key, e.Commitment...)
+	}
+	return key
+}
+
+// indexSubmitted records blobs included in the transaction of the receipt in the submission index.
+// Failures are logged, as the blobs were submitted regardless.
+func (c *CelestiaDA) indexSubmitted(ctx context.Context, receipt *Receipt, ns share.Namespace, blobs []*blob.Blob, gasPrice float64) {
+	if c.index == nil {
+		return
+	}
+	txSize := 0
+	for _, b := range blobs {
+		txSize += len(b.Data)
+	}
+	now := time.Now().UTC()
+	caller := CallerFromContext(ctx)
+	batch := new(leveldb.Batch)
+	for i, b := range blobs {
+		entry := &IndexEntry{
+			Namespace:  ns,
+			Commitment: b.Commitment,
+			Height:     receipt.Height,
+			Size:       len(b.Data),
+			TxSize:     txSize,
+			GasPrice:   gasPrice,
+			Time:       now,
+			Caller:     caller,
+			TxHash:     receipt.TxHash,
+			Fee:        receipt.Fee,
+			GasUsed:    receipt.GasUsed,
+			Shares:     receipt.Blobs[i].Shares,
+		}
+		value, err := json.Marshal(entry)
+		if err != nil {
+			log.Println("failed to index submitted blob", "height", receipt.Height, "error", err)
+			return
+		}
+		batch.Put(indexKey(indexPrefixCommitment, entry), value)
+		batch.Put(indexKey(indexPrefixNamespace, entry), value)
+	}
+	if err := c.index.Write(batch, nil); err != nil {
+		log.Println("failed to index submitted blobs", "height", receipt.Height, "error", err)
+	}
+}
+
+// LookupSubmission returns the index entries of blobs submitted with the given commitment, in
+// ascending order of height. The same blob submitted more than once has an entry per height.
+func (c *CelestiaDA) LookupSubmission(commitment da.Commitment) ([]*IndexEntry, error) {
+	if c.index == nil {
+		return nil, ErrIndexDisabled
+	}
+	prefix := append([]byte{indexPrefixCommitment}, commitment...)
+	iter := c.index.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iter.Release()
+	entries := []*IndexEntry{}
+	for iter.Next() {
+		// skip longer commitments sharing the prefix
+		if len(iter.Key()) != len(prefix)+heightLen {
+			continue
+		}
+		entry := new(IndexEntry)
+		if err := json.Unmarshal(iter.Value(), entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, iter.Error()
+}
+
+// IndexPage holds the index entries of a namespace over a range of heights.
+type IndexPage struct {
+	Entries []*IndexEntry `json:"entries"`
+	// Next is the height to continue listing from, or 0 if there are no more entries.
+	Next uint64 `json:"next,omitempty"`
+}
+
+// ListSubmissions returns the index entries of blobs submitted to the namespace at heights from
+// fromHeight, in ascending order of height. Up to limit entries are returned, or 100 if limit is 0,
+// extended to include all entries of the last height.
+func (c *CelestiaDA) ListSubmissions(ns da.Namespace, fromHeight uint64, limit int) (*IndexPage, error) {
+	if c.index == nil {
+		return nil, ErrIndexDisabled
+	}
+	namespace, err := c.defaultNamespace(ns)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultIndexLimit
+	}
+	limit = min(limit, maxIndexLimit)
+
+	prefix := append([]byte{indexPrefixNamespace}, namespace...)
+	start := binary.BigEndian.AppendUint64(bytes.Clone(prefix), fromHeight)
+	iter := c.index.NewIterator(&util.Range{Start: start, Limit: util.BytesPrefix(prefix).Limit}, nil)
+	defer iter.Release()
+	page := &IndexPage{Entries: []*IndexEntry{}}
+	for iter.Next() {
+		entry := new(IndexEntry)
+		if err := json.Unmarshal(iter.Value(), entry); err != nil {
+			return nil, err
+		}
+		if last := len(page.Entries); last >= limit && page.Entries[last-1].Height != entry.Height {
+			page.Next = entry.Height
+			break
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+	return page, iter.Error()
+}
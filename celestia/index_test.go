@@ -0,0 +1,68 @@
+package celestia
+
+import (
+	"context"
+	"testing"
+
+	rpc "github.com/celestiaorg/celestia-node/api/rpc/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCelestiaDA_Index(t *testing.T) {
+	m := setup(t)
+	defer teardown(m)
+	ctx := context.TODO()
+
+	_, err := m.LookupSubmission([]byte("commitment"))
+	assert.ErrorIs(t, err, ErrIndexDisabled)
+
+	client, err := rpc.NewClient(ctx, m.s.server.URL, "test")
+	require.NoError(t, err)
+	defer client.Close()
+	dir := t.TempDir()
+	indexed, err := NewCelestiaDAWithOptions(client, m.namespace, -1, ctx, WithIndex(dir))
+	require.NoError(t, err)
+
+	var ids []ID
+	for _, blobs := range [][]Blob{{[]byte("first"), []byte("second")}, {[]byte("third")}} {
+		submitted, err := indexed.Submit(ContextWithCaller(ctx, "rollup"), blobs, 0.5, nil)
+		require.NoError(t, err)
+		ids = append(ids, submitted...)
+	}
+
+	height, commitment := splitID(ids[1])
+	entries, err := indexed.LookupSubmission(commitment)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, ids[1], entry.ID())
+	assert.Equal(t, height, entry.Height)
+	assert.Equal(t, []byte(m.namespace), entry.Namespace)
+	assert.Equal(t, len("second"), entry.Size)
+	assert.Equal(t, len("first")+len("second"), entry.TxSize)
+	assert.Equal(t, 0.5, entry.GasPrice)
+	assert.Equal(t, "rollup", entry.Caller)
+	assert.False(t, entry.Time.IsZero())
+
+	// pages end at heights, so the two entries of the first height are returned together
+	page, err := indexed.ListSubmissions(nil, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 2)
+	next, _ := splitID(ids[2])
+	assert.Equal(t, next, page.Next)
+	page, err = indexed.ListSubmissions(nil, page.Next, 1)
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 1)
+	assert.Equal(t, ids[2], page.Entries[0].ID())
+	assert.Zero(t, page.Next)
+
+	// the index persists across restarts
+	require.NoError(t, indexed.Close())
+	indexed, err = NewCelestiaDAWithOptions(client, m.namespace, -1, ctx, WithIndex(dir))
+	require.NoError(t, err)
+	defer indexed.Close()
+	entries, err = indexed.LookupSubmission(commitment)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
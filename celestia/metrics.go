@@ -0,0 +1,52 @@
+package celestia
+
+import (
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/rollkit/celestia-da/internal/telemetry"
+)
+
+// daMetrics are the instruments shared by all CelestiaDA instances.
+var daMetrics = newMetrics()
+
+type metrics struct {
+	uncompressedBytes metric.Int64Counter
+	compressedBytes   metric.Int64Counter
+	compressionRatio  metric.Float64Histogram
+
+	queuePending  metric.Int64UpDownCounter
+	queueAttempts metric.Int64Counter
+
+	cacheHits   metric.Int64Counter
+	cacheMisses metric.Int64Counter
+	cacheBytes  metric.Int64UpDownCounter
+
+	balance metric.Int64ObservableGauge
+
+	upstreamHealthy metric.Int64ObservableGauge
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		uncompressedBytes: telemetry.Int64Counter("celestia_da_compression_uncompressed_bytes",
+			metric.WithDescription("Size of blobs before compression"), metric.WithUnit("By")),
+		compressedBytes: telemetry.Int64Counter("celestia_da_compression_compressed_bytes",
+			metric.WithDescription("Size of blobs after compression, including the blob header"), metric.WithUnit("By")),
+		compressionRatio: telemetry.Float64Histogram("celestia_da_compression_ratio",
+			metric.WithDescription("Ratio of compressed to uncompressed blob size")),
+		queuePending: telemetry.Int64UpDownCounter("celestia_da_queue_pending",
+			metric.WithDescription("Number of queued submissions waiting to be included")),
+		queueAttempts: telemetry.Int64Counter("celestia_da_queue_attempts",
+			metric.WithDescription("Number of queued submission attempts by result")),
+		cacheHits: telemetry.Int64Counter("celestia_da_cache_hits",
+			metric.WithDescription("Number of cache hits by entry kind and tier")),
+		cacheMisses: telemetry.Int64Counter("celestia_da_cache_misses",
+			metric.WithDescription("Number of cache misses by entry kind")),
+		cacheBytes: telemetry.Int64UpDownCounter("celestia_da_cache_bytes",
+			metric.WithDescription("Size of cached entries by tier"), metric.WithUnit("By")),
+		balance: telemetry.Int64ObservableGauge("celestia_da_balance",
+			metric.WithDescription("Balance of the node account by denomination")),
+		upstreamHealthy: telemetry.Int64ObservableGauge("celestia_da_upstream_healthy",
+			metric.WithDescription("Health of the celestia-node endpoints, 1 if healthy")),
+	}
+}
@@ -1,29 +1,118 @@
 package celestia
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/celestiaorg/celestia-app/pkg/shares"
 	"github.com/celestiaorg/celestia-node/blob"
+	"github.com/celestiaorg/celestia-node/header"
 	"github.com/celestiaorg/celestia-node/share"
+	"github.com/celestiaorg/celestia-node/state"
 	"github.com/celestiaorg/nmt"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/filecoin-project/go-jsonrpc"
 )
 
 // MockBlobAPI mocks the blob API
+//
+// Submitted blobs are stored and returned by Get and GetAll, other requests return an example blob.
+// Get returns blob.ErrBlobNotFound for unknown commitments at heights with submitted blobs.
 type MockBlobAPI struct {
+	mu     sync.Mutex
 	height uint64
+	blobs  map[uint64][]*blob.Blob
+	// failures holds the errors returned by read requests at a height.
+	failures map[uint64]error
+	// getAlls counts the GetAll requests.
+	getAlls int
 }
 
 // Submit mocks the blob.Submit method
 func (m *MockBlobAPI) Submit(ctx context.Context, blobs []*blob.Blob, gasPrice float64) (uint64, error) {
+	return m.store(blobs)
+}
+
+// store stores blobs at the next height, with the share indices they would occupy in an otherwise
+// empty data square.
+func (m *MockBlobAPI) store(blobs []*blob.Blob) (uint64, error) {
+	stored := make([]*blob.Blob, len(blobs))
+	index := 1
+	for i, b := range blobs {
+		var err error
+		if stored[i], err = withIndex(b, index); err != nil {
+			return 0, err
+		}
+		index += shares.SparseSharesNeeded(uint32(len(b.Data)))
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.height += 1
+	if m.blobs == nil {
+		m.blobs = make(map[uint64][]*blob.Blob)
+	}
+	m.blobs[m.height] = stored
 	return m.height, nil
 }
 
+// withIndex returns a copy of b with the given share index, which is only settable through JSON.
+func withIndex(b *blob.Blob, index int) (*blob.Blob, error) {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	fields["index"] = json.RawMessage(strconv.Itoa(index))
+	if data, err = json.Marshal(fields); err != nil {
+		return nil, err
+	}
+	out := new(blob.Blob)
+	return out, json.Unmarshal(data, out)
+}
+
+// stored returns the blobs submitted at the given height, or the failure set for it.
+func (m *MockBlobAPI) stored(height uint64) ([]*blob.Blob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.blobs[height], m.failures[height]
+}
+
+// fail makes read requests at the given height return err.
+func (m *MockBlobAPI) fail(height uint64, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failures == nil {
+		m.failures = make(map[uint64]error)
+	}
+	m.failures[height] = err
+}
+
 // Get mocks the blob.Get method
-func (m *MockBlobAPI) Get(ctx context.Context, height uint64, ns share.Namespace, _ blob.Commitment) (*blob.Blob, error) {
+func (m *MockBlobAPI) Get(ctx context.Context, height uint64, ns share.Namespace, commitment blob.Commitment) (*blob.Blob, error) {
+	stored, err := m.stored(height)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range stored {
+		if bytes.Equal(b.Commitment, commitment) {
+			return b, nil
+		}
+	}
+	if len(stored) > 0 {
+		return nil, blob.ErrBlobNotFound
+	}
 	data, err := hex.DecodeString("5468697320697320616e206578616d706c65206f6620736f6d6520626c6f622064617461")
 	if err != nil {
 		return nil, err
@@ -36,6 +125,16 @@ func (m *MockBlobAPI) GetAll(ctx context.Context, height uint64, ns []share.Name
 	if height == 0 {
 		return []*blob.Blob{}, nil
 	}
+	m.mu.Lock()
+	m.getAlls++
+	m.mu.Unlock()
+	blobs, err := m.stored(height)
+	if err != nil {
+		return nil, err
+	}
+	if len(blobs) > 0 {
+		return blobs, nil
+	}
 	data, err := hex.DecodeString("5468697320697320616e206578616d706c65206f6620736f6d6520626c6f622064617461")
 	if err != nil {
 		return nil, err
@@ -48,7 +147,10 @@ func (m *MockBlobAPI) GetAll(ctx context.Context, height uint64, ns []share.Name
 }
 
 // GetProof mocks the blob.GetProof method
-func (m *MockBlobAPI) GetProof(context.Context, uint64, share.Namespace, blob.Commitment) (*blob.Proof, error) {
+func (m *MockBlobAPI) GetProof(_ context.Context, height uint64, _ share.Namespace, _ blob.Commitment) (*blob.Proof, error) {
+	if _, err := m.stored(height); err != nil {
+		return nil, err
+	}
 	proof := nmt.NewInclusionProof(0, 4, [][]byte{[]byte("test")}, true)
 	return &blob.Proof{&proof}, nil
 }
@@ -58,9 +160,129 @@ func (m *MockBlobAPI) Included(context.Context, uint64, share.Namespace, *blob.P
 	return true, nil
 }
 
+// getAllCalls returns the number of GetAll requests.
+func (m *MockBlobAPI) getAllCalls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.getAlls
+}
+
+// currentHeight returns the height of the last submission.
+func (m *MockBlobAPI) currentHeight() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.height
+}
+
+// MockHeaderAPI mocks the header API, with the height advancing on every submission
+type MockHeaderAPI struct {
+	blob *MockBlobAPI
+}
+
+// LocalHead mocks the header.LocalHead method
+func (m *MockHeaderAPI) LocalHead(context.Context) (*header.ExtendedHeader, error) {
+	return mockHeader(m.blob.currentHeight()), nil
+}
+
+// WaitForHeight mocks the header.WaitForHeight method
+func (m *MockHeaderAPI) WaitForHeight(ctx context.Context, height uint64) (*header.ExtendedHeader, error) {
+	for m.blob.currentHeight() < height {
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return mockHeader(height), nil
+}
+
+func mockHeader(height uint64) *header.ExtendedHeader {
+	return &header.ExtendedHeader{RawHeader: header.RawHeader{Height: int64(height)}}
+}
+
+// MockStateAPI mocks the state API, storing submitted blobs in the blob API
+type MockStateAPI struct {
+	blob *MockBlobAPI
+
+	mu      sync.Mutex
+	balance int64
+	// failures holds the outcomes of the next submissions, see failSubmit.
+	failures []submitFailure
+}
+
+// submitFailure is the outcome of a submission, which fails with err unless it is nil. The blobs
+// of failed submissions are stored if included is set.
+type submitFailure struct {
+	err      error
+	included bool
+}
+
+// mockBalance is the initial balance of the mock account, in utia.
+const mockBalance = 1_000_000_000
+
+// Balance mocks the state.Balance method
+func (m *MockStateAPI) Balance(context.Context) (*state.Balance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	coin := sdk.NewInt64Coin("utia", m.balance)
+	return &coin, nil
+}
+
+// setBalance sets the balance of the mock account.
+func (m *MockStateAPI) setBalance(balance int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.balance = balance
+}
+
+// failSubmit sets the outcomes of the next submissions in order, later submissions succeed.
+func (m *MockStateAPI) failSubmit(failures ...submitFailure) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures = failures
+}
+
+// SubmitPayForBlob mocks the state.SubmitPayForBlob method, the transaction uses half its gas limit
+// and the fee is deducted from the balance
+func (m *MockStateAPI) SubmitPayForBlob(_ context.Context, fee state.Int, gasLim uint64, blobs []*blob.Blob) (*state.TxResponse, error) {
+	m.mu.Lock()
+	var failure submitFailure
+	if len(m.failures) > 0 {
+		failure, m.failures = m.failures[0], m.failures[1:]
+	}
+	if failure.err != nil && !failure.included {
+		m.mu.Unlock()
+		return nil, failure.err
+	}
+	if fee.Int64() > m.balance {
+		m.mu.Unlock()
+		return nil, errors.New("insufficient funds")
+	}
+	m.balance -= fee.Int64()
+	m.mu.Unlock()
+	height, err := m.blob.store(blobs)
+	if err != nil {
+		return nil, err
+	}
+	if failure.err != nil {
+		return nil, failure.err
+	}
+	hash := sha256.New()
+	for _, b := range blobs {
+		hash.Write(b.Commitment)
+	}
+	return &state.TxResponse{
+		Height:    int64(height),
+		TxHash:    strings.ToUpper(hex.EncodeToString(hash.Sum(nil))),
+		GasWanted: int64(gasLim),
+		GasUsed:   int64(gasLim / 2),
+	}, nil
+}
+
 // MockService mocks the node RPC service
 type MockService struct {
 	blob   *MockBlobAPI
+	state  *MockStateAPI
 	server *httptest.Server
 }
 
@@ -69,17 +291,26 @@ func (m *MockService) Close() {
 	m.server.Close()
 }
 
+// URL returns the address of the server
+func (m *MockService) URL() string {
+	return m.server.URL
+}
+
 // NewMockService returns the mock service
 func NewMockService() *MockService {
 	rpcServer := jsonrpc.NewServer()
 
 	blobAPI := &MockBlobAPI{}
 	rpcServer.Register("blob", blobAPI)
+	rpcServer.Register("header", &MockHeaderAPI{blob: blobAPI})
+	stateAPI := &MockStateAPI{blob: blobAPI, balance: mockBalance}
+	rpcServer.Register("state", stateAPI)
 
 	testServ := httptest.NewServer(rpcServer)
 
 	mockService := &MockService{
 		blob:   blobAPI,
+		state:  stateAPI,
 		server: testServ,
 	}
 
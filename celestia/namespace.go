@@ -0,0 +1,156 @@
+package celestia
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	appns "github.com/celestiaorg/celestia-app/pkg/namespace"
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+var (
+	// ErrInvalidNamespace is returned when a namespace can't be decoded or has an invalid size.
+	ErrInvalidNamespace = errors.New("invalid namespace")
+	// ErrUnsupportedNamespaceVersion is returned for namespace versions that can't be used for blobs.
+	ErrUnsupportedNamespaceVersion = errors.New("unsupported namespace version")
+	// ErrReservedNamespace is returned for namespaces reserved for protocol use.
+	ErrReservedNamespace = errors.New("reserved namespace")
+	// ErrParityNamespace is returned for the namespace of erasure coded parity shares.
+	ErrParityNamespace = errors.New("parity shares namespace")
+	// ErrTailPaddingNamespace is returned for the namespace of tail padding shares.
+	ErrTailPaddingNamespace = errors.New("tail padding namespace")
+)
+
+// namespaceRules validates the ID of a namespace for a specific namespace version.
+//
+// Versions without an entry are not supported for blobs.
+var namespaceRules = map[uint8]func(id []byte) error{
+	appns.NamespaceVersionZero: func(id []byte) error {
+		if !bytes.HasPrefix(id, appns.NamespaceVersionZeroPrefix) {
+			return fmt.Errorf("%w: version 0 namespace ID must start with %d zero bytes",
+				ErrInvalidNamespace, appns.NamespaceVersionZeroPrefixSize)
+		}
+		return nil
+	},
+	// NamespaceVersionMax is used only by the secondary reserved namespaces.
+	appns.NamespaceVersionMax: func(id []byte) error {
+		return fmt.Errorf("%w: version %d is reserved for protocol use", ErrReservedNamespace, appns.NamespaceVersionMax)
+	},
+}
+
+// ParseNamespace decodes a namespace given as a hex (optionally 0x prefixed) or base64 string.
+//
+// See NamespaceFromBytes for accepted lengths.
+func ParseNamespace(s string) (share.Namespace, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("%w: empty namespace", ErrInvalidNamespace)
+	}
+	b, err := DecodeNamespace(s)
+	if err != nil {
+		return nil, err
+	}
+	return NamespaceFromBytes(b)
+}
+
+// namespaceDerivationTag is a domain separator for DeriveNamespace. It must never change, as
+// that would change every derived namespace.
+const namespaceDerivationTag = "celestia-da/namespace/v0"
+
+// DeriveNamespace deterministically maps a rollup chain ID and an optional salt to a version 0
+// namespace.
+//
+// The namespace ID is the first 10 bytes of a SHA-256 hash over the length prefixed chain ID and
+// salt, so the same inputs always result in the same namespace. A salt can be used to derive
+// multiple namespaces for a single chain.
+func DeriveNamespace(chainID string, salt []byte) (share.Namespace, error) {
+	if chainID == "" {
+		return nil, fmt.Errorf("%w: chain ID is required", ErrInvalidNamespace)
+	}
+	h := sha256.New()
+	h.Write([]byte(namespaceDerivationTag))
+	for _, part := range [][]byte{[]byte(chainID), salt} {
+		var size [8]byte
+		binary.BigEndian.PutUint64(size[:], uint64(len(part)))
+		h.Write(size[:])
+		h.Write(part)
+	}
+	return NamespaceFromBytes(h.Sum(nil)[:appns.NamespaceVersionZeroIDSize])
+}
+
+// NamespaceFromBytes returns a validated namespace.
+//
+// A full 29 byte namespace (version byte followed by ID) is accepted for every version
+// supported for blobs. Shorter inputs, up to 10 bytes, are treated as a version 0 ID and
+// left padded with zeros, which matches the legacy behaviour of the --da.grpc.namespace flag.
+func NamespaceFromBytes(b []byte) (share.Namespace, error) {
+	ns := make(share.Namespace, appns.NamespaceSize)
+	switch {
+	case len(b) == appns.NamespaceSize:
+		copy(ns, b)
+	case len(b) > 0 && len(b) <= appns.NamespaceVersionZeroIDSize:
+		copy(ns[appns.NamespaceSize-len(b):], b)
+	default:
+		return nil, fmt.Errorf("%w: got %d bytes, expected %d byte namespace or up to %d byte version 0 ID",
+			ErrInvalidNamespace, len(b), appns.NamespaceSize, appns.NamespaceVersionZeroIDSize)
+	}
+	if err := ValidateNamespace(ns); err != nil {
+		return nil, err
+	}
+	return ns, nil
+}
+
+// ValidateNamespace checks that ns can be used to submit and retrieve blobs.
+func ValidateNamespace(ns []byte) error {
+	if len(ns) != appns.NamespaceSize {
+		return fmt.Errorf("%w: got %d bytes, expected %d", ErrInvalidNamespace, len(ns), appns.NamespaceSize)
+	}
+	version, id := ns[0], ns[appns.NamespaceVersionSize:]
+	appNs := appns.Namespace{Version: version, ID: id}
+	switch {
+	case appNs.IsParityShares():
+		return ErrParityNamespace
+	case appNs.IsTailPadding():
+		return ErrTailPaddingNamespace
+	}
+
+	rule, ok := namespaceRules[version]
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrUnsupportedNamespaceVersion, version)
+	}
+	if err := rule(id); err != nil {
+		return err
+	}
+	if appNs.IsReserved() {
+		return fmt.Errorf("%w: %s", ErrReservedNamespace, hex.EncodeToString(ns))
+	}
+	return nil
+}
+
+// DecodeNamespace decodes a hex (optionally 0x prefixed) or base64 string without validating it.
+//
+// Hex is tried first, as it's the documented format.
+func DecodeNamespace(s string) ([]byte, error) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		b, err := hex.DecodeString(s[2:])
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid hex value: %v", ErrInvalidNamespace, err)
+		}
+		return b, nil
+	}
+	if b, err := hex.DecodeString(s); err == nil {
+		return b, nil
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if b, err := enc.DecodeString(s); err == nil {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q is neither hex nor base64 encoded", ErrInvalidNamespace, s)
+}
@@ -0,0 +1,95 @@
+package celestia
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	appns "github.com/celestiaorg/celestia-app/pkg/namespace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNamespace(t *testing.T) {
+	id, err := hex.DecodeString("0000c9761e8b221ae42f")
+	assert.NoError(t, err)
+	full := append(make([]byte, 1+appns.NamespaceVersionZeroPrefixSize), id...)
+
+	tests := []struct {
+		name  string
+		input string
+		want  []byte
+		err   error
+	}{
+		{"hex_id", "0000c9761e8b221ae42f", full, nil},
+		{"hex_id_0x", "0x0000c9761e8b221ae42f", full, nil},
+		{"hex_full", hex.EncodeToString(full), full, nil},
+		{"base64_full", base64.StdEncoding.EncodeToString(full), full, nil},
+		{"base64_id", base64.RawURLEncoding.EncodeToString(id), full, nil},
+		{"empty", "", nil, ErrInvalidNamespace},
+		{"garbage", "not a namespace!", nil, ErrInvalidNamespace},
+		{"bad_0x", "0xzz", nil, ErrInvalidNamespace},
+		{"too_long_id", hex.EncodeToString(bytes.Repeat([]byte{1}, 11)), nil, ErrInvalidNamespace},
+		{"tx", hex.EncodeToString(appns.TxNamespace.Bytes()), nil, ErrReservedNamespace},
+		{"primary_padding", hex.EncodeToString(appns.PrimaryReservedPaddingNamespace.Bytes()), nil, ErrReservedNamespace},
+		{"parity", hex.EncodeToString(appns.ParitySharesNamespace.Bytes()), nil, ErrParityNamespace},
+		{"tail_padding", hex.EncodeToString(appns.TailPaddingNamespace.Bytes()), nil, ErrTailPaddingNamespace},
+		{"secondary_reserved", hex.EncodeToString(appns.MinSecondaryReservedNamespace.Bytes()), nil, ErrReservedNamespace},
+		{"v0_bad_prefix", hex.EncodeToString(append([]byte{0}, bytes.Repeat([]byte{1}, appns.NamespaceIDSize)...)), nil, ErrInvalidNamespace},
+		{"unsupported_version", hex.EncodeToString(append([]byte{1}, bytes.Repeat([]byte{1}, appns.NamespaceIDSize)...)), nil, ErrUnsupportedNamespaceVersion},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ns, err := ParseNamespace(tc.input)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				assert.Nil(t, ns)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, []byte(ns))
+		})
+	}
+}
+
+func TestCelestiaDA_InvalidNamespace(t *testing.T) {
+	m := setup(t)
+	defer teardown(m)
+
+	ns := appns.TxNamespace.Bytes()
+	_, err := m.Submit(m.ctx, []Blob{[]byte{0x00}}, -1, ns)
+	assert.ErrorIs(t, err, ErrReservedNamespace)
+	_, err = m.GetIDs(m.ctx, 42, ns)
+	assert.ErrorIs(t, err, ErrReservedNamespace)
+	_, err = m.Get(m.ctx, nil, make([]byte, appns.NamespaceSize+1))
+	assert.ErrorIs(t, err, ErrInvalidNamespace)
+}
+
+func TestDeriveNamespace(t *testing.T) {
+	ns1, err := DeriveNamespace("rollup-1", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, ValidateNamespace(ns1))
+	assert.Equal(t, uint8(0), ns1[0])
+
+	again, err := DeriveNamespace("rollup-1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, ns1, again)
+
+	salted, err := DeriveNamespace("rollup-1", []byte("blocks"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, ns1, salted)
+
+	// length prefixes prevent chain ID and salt from running into each other
+	a, err := DeriveNamespace("ab", []byte("c"))
+	assert.NoError(t, err)
+	b, err := DeriveNamespace("a", []byte("bc"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, a, b)
+
+	parsed, err := ParseNamespace(hex.EncodeToString(ns1))
+	assert.NoError(t, err)
+	assert.Equal(t, ns1, parsed)
+
+	_, err = DeriveNamespace("", nil)
+	assert.ErrorIs(t, err, ErrInvalidNamespace)
+}
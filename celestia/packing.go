@@ -0,0 +1,29 @@
+package celestia
+
+import (
+	"github.com/celestiaorg/celestia-node/blob"
+)
+
+// packBlobs splits blobs into consecutive batches, each submitted in its own transaction, so that
+// the total data size of a batch doesn't exceed maxSize.
+//
+// Batches preserve the order of the blobs. A blob larger than maxSize is put in a batch on its own,
+// leaving it to the node to reject it.
+func packBlobs(blobs []*blob.Blob, maxSize uint64) [][]*blob.Blob {
+	var batches [][]*blob.Blob
+	var batch []*blob.Blob
+	var size uint64
+	for _, b := range blobs {
+		blobSize := uint64(len(b.Data))
+		if len(batch) > 0 && size+blobSize > maxSize {
+			batches = append(batches, batch)
+			batch, size = nil, 0
+		}
+		batch = append(batch, b)
+		size += blobSize
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
@@ -0,0 +1,76 @@
+package celestia
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/celestiaorg/celestia-node/blob"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackBlobs(t *testing.T) {
+	ns, err := share.NewBlobNamespaceV0([]byte("packing"))
+	assert.NoError(t, err)
+	sizes := []int{40, 30, 30, 120, 10, 90}
+	var blobs []*blob.Blob
+	for i, size := range sizes {
+		b, err := blob.NewBlobV0(ns, bytes.Repeat([]byte{byte(i)}, size))
+		assert.NoError(t, err)
+		blobs = append(blobs, b)
+	}
+
+	var packed [][]int
+	for _, batch := range packBlobs(blobs, 100) {
+		var batchSizes []int
+		for _, b := range batch {
+			batchSizes = append(batchSizes, len(b.Data))
+		}
+		packed = append(packed, batchSizes)
+	}
+	assert.Equal(t, [][]int{{40, 30, 30}, {120}, {10, 90}}, packed)
+	assert.Empty(t, packBlobs(nil, 100))
+}
+
+func TestCelestiaDA_Packing(t *testing.T) {
+	m := setup(t)
+	defer teardown(m)
+	ctx := context.TODO()
+
+	third := bytes.Repeat([]byte{0x01}, DefaultMaxBytes/3+1)
+	data := []Blob{third, bytes.Repeat([]byte{0x02}, len(third)), bytes.Repeat([]byte{0x03}, len(third)), []byte("small")}
+	ids, err := m.Submit(ctx, data, -1, nil)
+	assert.NoError(t, err)
+	assert.Len(t, ids, len(data))
+
+	var heights []uint64
+	for _, id := range ids {
+		height, _ := splitID(id)
+		heights = append(heights, height)
+	}
+	assert.Equal(t, []uint64{heights[0], heights[0], heights[0] + 1, heights[0] + 1}, heights)
+
+	blobs, err := m.Get(ctx, ids, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, data, blobs)
+
+	t.Run("Partial", func(t *testing.T) {
+		failed := errors.New("failed")
+		m.s.state.failSubmit(submitFailure{}, submitFailure{err: failed})
+		ids, err := m.Submit(ctx, data, -1, nil)
+		var submitErr *SubmitError
+		require.ErrorAs(t, err, &submitErr)
+		assert.ErrorContains(t, err, failed.Error())
+		assert.Equal(t, len(data), submitErr.Blobs)
+		assert.Len(t, submitErr.Receipts, 1)
+		assert.Len(t, ids, 2)
+		assert.Equal(t, ids, submitErr.Progress.IDs)
+
+		blobs, err := m.Get(ctx, ids, nil)
+		require.NoError(t, err)
+		assert.Equal(t, data[:2], blobs)
+	})
+}
@@ -0,0 +1,660 @@
+package celestia
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/rollkit/go-da"
+)
+
+var (
+	// ErrUnknownTicket is returned for tickets that were never issued by the queue, or were pruned.
+	ErrUnknownTicket = errors.New("unknown submission ticket")
+	// ErrSubmissionPending is returned when a pending ID is used before its submission completed.
+	ErrSubmissionPending = errors.New("submission is pending")
+	// ErrSubmissionFailed is returned when a pending ID is used after its submission failed.
+	ErrSubmissionFailed = errors.New("submission failed")
+)
+
+// SubmissionState is the state of a queued submission.
+type SubmissionState string
+
+const (
+	// SubmissionPending submissions are waiting to be included.
+	SubmissionPending SubmissionState = "pending"
+	// SubmissionCompleted submissions were included, and have final IDs.
+	SubmissionCompleted SubmissionState = "completed"
+	// SubmissionFailed submissions were given up on after the maximum number of attempts, or after
+	// an attempt failed with an error that further attempts fail with as well.
+	SubmissionFailed SubmissionState = "failed"
+)
+
+// SubmissionStatus is the status of a queued submission.
+type SubmissionStatus struct {
+	Ticket string          `json:"ticket"`
+	State  SubmissionState `json:"state"`
+	// IDs are the final IDs of the submitted blobs, set once the submission completed. Failed
+	// submissions hold the IDs of the leading blobs that were included before the failure.
+	IDs []da.ID `json:"ids,omitempty"`
+	// Error is the error of the last failed attempt.
+	Error    string    `json:"error,omitempty"`
+	Attempts int       `json:"attempts"`
+	Created  time.Time `json:"created"`
+	Updated  time.Time `json:"updated"`
+}
+
+// Final returns true if the submission completed or failed.
+func (s *SubmissionStatus) Final() bool {
+	return s.State != SubmissionPending
+}
+
+// ticketSize is the size of a ticket in bytes.
+const ticketSize = 16
+
+// TicketFromID returns the ticket of a pending ID returned by Queue.Submit, and the index of the blob
+// in the submission.
+//
+// Pending IDs use height 0, which is never a valid inclusion height, followed by the ticket and
+// the index of the blob as 4 byte big endian integer.
+func TicketFromID(id da.ID) (string, int, bool) {
+	height, rest := splitID(id)
+	if height != 0 || len(rest) != ticketSize+4 {
+		return "", 0, false
+	}
+	return hex.EncodeToString(rest[:ticketSize]), int(binary.BigEndian.Uint32(rest[ticketSize:])), true
+}
+
+func pendingID(ticket []byte, index int) da.ID {
+	rest := binary.BigEndian.AppendUint32(append([]byte{}, ticket...), uint32(index))
+	return makeID(0, rest)
+}
+
+// QueueConfig configures the submission queue.
+type QueueConfig struct {
+	// Dir is the directory of the write-ahead log.
+	Dir string `json:"dir"`
+	// MaxAttempts is the number of attempts before a submission fails, 0 retries forever.
+	MaxAttempts int `json:"max_attempts"`
+	// RetryInterval is the delay after the first failed attempt, doubled after each further attempt.
+	RetryInterval time.Duration `json:"retry_interval"`
+	// MaxRetryInterval bounds the delay between attempts.
+	MaxRetryInterval time.Duration `json:"max_retry_interval"`
+	// RetainFinished is the number of completed or failed submissions kept for status queries.
+	RetainFinished int `json:"retain_finished"`
+}
+
+// DefaultQueueConfig returns the default configuration for a queue storing its log in dir.
+func DefaultQueueConfig(dir string) QueueConfig {
+	return QueueConfig{
+		Dir:              dir,
+		MaxAttempts:      0,
+		RetryInterval:    time.Second,
+		MaxRetryInterval: time.Minute,
+		RetainFinished:   10000,
+	}
+}
+
+// walRecord is a queue log record. Submit records hold the blobs of a new submission, later pending
+// records the progress of failed attempts, and completed and failed records finish it.
+type walRecord struct {
+	State     SubmissionState `json:"state"`
+	Ticket    string          `json:"ticket"`
+	Namespace []byte          `json:"namespace,omitempty"`
+	GasPrice  float64         `json:"gas_price,omitempty"`
+	Caller    string          `json:"caller,omitempty"`
+	Blobs     [][]byte        `json:"blobs,omitempty"`
+	IDs       [][]byte        `json:"ids,omitempty"`
+	Progress  *SubmitProgress `json:"progress,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Attempts  int             `json:"attempts,omitempty"`
+	Created   time.Time       `json:"created"`
+	Updated   time.Time       `json:"updated"`
+}
+
+type queueEntry struct {
+	status    SubmissionStatus
+	namespace []byte
+	gasPrice  float64
+	caller    string
+	blobs     [][]byte
+	// progress is the progress of the last failed attempt, resumed by the next attempt.
+	progress *SubmitProgress
+}
+
+// Queue submits blobs asynchronously. Submit persists blobs to a write-ahead log and returns
+// pending IDs right away, while a background worker submits queued blobs in order, retrying failed
+// attempts. Pending submissions survive restarts, and are held while submissions are paused.
+//
+// Attempts that fail with a *SubmitError persist its progress, and the next attempt resumes the
+// submission with ResumeSubmit, so blobs that were included aren't paid for twice. Submissions
+// failing with an error that doesn't go away on retry, like ErrBlobRejected, fail without further
+// attempts.
+//
+// Get, GetProofs and Validate accept pending IDs once their submission completed, other methods
+// are passed through to CelestiaDA.
+type Queue struct {
+	*CelestiaDA
+	config QueueConfig
+
+	mu       sync.Mutex
+	wal      *wal
+	entries  map[string]*queueEntry
+	pending  []string
+	finished []string
+	// changed is closed and replaced whenever the status of a submission changes.
+	changed chan struct{}
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewQueue opens the write-ahead log in config.Dir, and starts submitting pending blobs with c.
+func NewQueue(c *CelestiaDA, config QueueConfig) (*Queue, error) {
+	if config.Dir == "" {
+		return nil, errors.New("queue directory is required")
+	}
+	if config.RetryInterval <= 0 || config.MaxRetryInterval < config.RetryInterval {
+		return nil, fmt.Errorf("invalid queue retry intervals %s and %s", config.RetryInterval, config.MaxRetryInterval)
+	}
+	w, records, err := openWAL(config.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open queue log: %w", err)
+	}
+	q := &Queue{
+		CelestiaDA: c,
+		config:     config,
+		wal:        w,
+		entries:    make(map[string]*queueEntry),
+		changed:    make(chan struct{}),
+		wake:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+	for _, record := range records {
+		q.replay(record)
+	}
+	if err := q.compact(); err != nil {
+		w.close()
+		return nil, fmt.Errorf("failed to compact queue log: %w", err)
+	}
+	if len(q.pending) > 0 {
+		log.Println("resuming queued submissions", "count", len(q.pending))
+	}
+	daMetrics.queuePending.Add(context.Background(), int64(len(q.pending)))
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	q.cancel = cancel
+	go q.run(ctx)
+	q.notify()
+	return q, nil
+}
+
+// Close stops the worker and closes the log. Pending submissions are resumed by the next queue
+// opened on the same directory.
+func (q *Queue) Close() error {
+	q.cancel()
+	<-q.done
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	daMetrics.queuePending.Add(context.Background(), -int64(len(q.pending)))
+	return q.wal.close()
+}
+
+// Submit queues blobs for submission, and returns a pending ID for each blob.
+func (q *Queue) Submit(ctx context.Context, daBlobs []da.Blob, gasPrice float64, ns da.Namespace) ([]da.ID, error) {
+	namespace, err := q.defaultNamespace(ns)
+	if err != nil {
+		return nil, err
+	}
+	if len(daBlobs) == 0 {
+		return nil, nil
+	}
+	// blobs that can never be submitted are refused before they are persisted
+	data, err := q.encodeBlobs(daBlobs, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.checkBlobSizes(ctx, data); err != nil {
+		return nil, err
+	}
+	ticket := make([]byte, ticketSize)
+	if _, err := rand.Read(ticket); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	record := walRecord{
+		State:     SubmissionPending,
+		Ticket:    hex.EncodeToString(ticket),
+		Namespace: namespace,
+		GasPrice:  gasPrice,
+		Caller:    CallerFromContext(ctx),
+		Blobs:     daBlobs,
+		Created:   now,
+		Updated:   now,
+	}
+	// submissions the log can't hold are refused rather than lost on the next restart
+	buf, err := encodeWALRecords([]walRecord{record})
+	if err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	if err := q.wal.write(buf, 1); err != nil {
+		q.mu.Unlock()
+		return nil, fmt.Errorf("failed to persist submission: %w", err)
+	}
+	q.replay(record)
+	q.broadcast()
+	q.mu.Unlock()
+	daMetrics.queuePending.Add(ctx, 1)
+	q.notify()
+
+	ids := make([]da.ID, len(daBlobs))
+	for i := range daBlobs {
+		ids[i] = pendingID(ticket, i)
+	}
+	return ids, nil
+}
+
+// Status returns the status of the submission with the given ticket.
+func (q *Queue) Status(ticket string) (*SubmissionStatus, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	status, _, err := q.status(ticket)
+	return status, err
+}
+
+// Watch sends the status of the submission with the given ticket, and every change of it, until the
+// submission is final or ctx is done. The channel is closed afterwards.
+func (q *Queue) Watch(ctx context.Context, ticket string) (<-chan SubmissionStatus, error) {
+	q.mu.Lock()
+	status, changed, err := q.status(ticket)
+	q.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan SubmissionStatus, 1)
+	go func() {
+		defer close(ch)
+		for {
+			select {
+			case ch <- *status:
+			case <-ctx.Done():
+				return
+			}
+			if status.Final() {
+				return
+			}
+			updated := status.Updated
+			for status.Updated.Equal(updated) && !status.Final() {
+				select {
+				case <-changed:
+				case <-ctx.Done():
+					return
+				}
+				q.mu.Lock()
+				status, changed, err = q.status(ticket)
+				q.mu.Unlock()
+				if err != nil {
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// QueueConfig returns the configuration of the queue.
+func (q *Queue) QueueConfig() QueueConfig {
+	return q.config
+}
+
+// Pending returns the status of the pending submissions, in order of submission.
+func (q *Queue) Pending() []SubmissionStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	pending := make([]SubmissionStatus, len(q.pending))
+	for i, ticket := range q.pending {
+		pending[i] = q.entries[ticket].status
+	}
+	return pending
+}
+
+// Get returns Blob for each given ID, resolving pending IDs of completed submissions.
+func (q *Queue) Get(ctx context.Context, ids []da.ID, ns da.Namespace) ([]da.Blob, error) {
+	resolved, err := q.resolve(ids)
+	if err != nil {
+		return nil, err
+	}
+	return q.CelestiaDA.Get(ctx, resolved, ns)
+}
+
+// GetProofs returns the inclusion proofs for the given IDs, resolving pending IDs of completed submissions.
+func (q *Queue) GetProofs(ctx context.Context, ids []da.ID, ns da.Namespace) ([]da.Proof, error) {
+	resolved, err := q.resolve(ids)
+	if err != nil {
+		return nil, err
+	}
+	return q.CelestiaDA.GetProofs(ctx, resolved, ns)
+}
+
+// Validate validates Commitments against the corresponding Proofs, resolving pending IDs of completed submissions.
+func (q *Queue) Validate(ctx context.Context, ids []da.ID, proofs []da.Proof, ns da.Namespace) ([]bool, error) {
+	resolved, err := q.resolve(ids)
+	if err != nil {
+		return nil, err
+	}
+	return q.CelestiaDA.Validate(ctx, resolved, proofs, ns)
+}
+
+// resolve replaces pending IDs with the final IDs of their submissions.
+func (q *Queue) resolve(ids []da.ID) ([]da.ID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	resolved := make([]da.ID, len(ids))
+	for i, id := range ids {
+		ticket, index, ok := TicketFromID(id)
+		if !ok {
+			resolved[i] = id
+			continue
+		}
+		status, _, err := q.status(ticket)
+		if err != nil {
+			return nil, err
+		}
+		switch status.State {
+		case SubmissionPending:
+			return nil, fmt.Errorf("%w: ticket %s", ErrSubmissionPending, ticket)
+		case SubmissionFailed:
+			return nil, fmt.Errorf("%w: ticket %s: %s", ErrSubmissionFailed, ticket, status.Error)
+		}
+		if index >= len(status.IDs) {
+			return nil, fmt.Errorf("%w: ticket %s has no blob %d", ErrUnknownTicket, ticket, index)
+		}
+		resolved[i] = status.IDs[index]
+	}
+	return resolved, nil
+}
+
+// status returns a copy of the status of a submission, and the channel closed on the next change.
+func (q *Queue) status(ticket string) (*SubmissionStatus, chan struct{}, error) {
+	entry, ok := q.entries[ticket]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownTicket, ticket)
+	}
+	status := entry.status
+	return &status, q.changed, nil
+}
+
+// replay applies a log record to the in-memory state.
+func (q *Queue) replay(record walRecord) {
+	entry, ok := q.entries[record.Ticket]
+	if record.State == SubmissionPending {
+		if ok {
+			if record.Progress != nil && !entry.status.Final() {
+				entry.progress = record.Progress
+				entry.status.Error = record.Error
+				entry.status.Attempts = record.Attempts
+				entry.status.Updated = record.Updated
+			}
+			return
+		}
+		q.entries[record.Ticket] = &queueEntry{
+			status: SubmissionStatus{
+				Ticket:  record.Ticket,
+				State:   SubmissionPending,
+				Created: record.Created,
+				Updated: record.Updated,
+			},
+			namespace: record.Namespace,
+			gasPrice:  record.GasPrice,
+			caller:    record.Caller,
+			blobs:     record.Blobs,
+			progress:  record.Progress,
+		}
+		q.pending = append(q.pending, record.Ticket)
+		return
+	}
+
+	if !ok {
+		entry = &queueEntry{status: SubmissionStatus{Ticket: record.Ticket, Created: record.Created}}
+		q.entries[record.Ticket] = entry
+	} else if entry.status.Final() {
+		return
+	} else {
+		q.removePending(record.Ticket)
+	}
+	entry.status.State = record.State
+	entry.status.IDs = record.IDs
+	entry.status.Error = record.Error
+	entry.status.Attempts = record.Attempts
+	entry.status.Updated = record.Updated
+	entry.blobs, entry.progress = nil, nil
+	q.finished = append(q.finished, record.Ticket)
+	for len(q.finished) > q.config.RetainFinished {
+		delete(q.entries, q.finished[0])
+		q.finished = q.finished[1:]
+	}
+}
+
+func (q *Queue) removePending(ticket string) {
+	for i, t := range q.pending {
+		if t == ticket {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// compact rewrites the log with the retained finished submissions followed by the pending ones.
+func (q *Queue) compact() error {
+	records := make([]walRecord, 0, len(q.finished)+len(q.pending))
+	for _, ticket := range append(append([]string{}, q.finished...), q.pending...) {
+		entry := q.entries[ticket]
+		records = append(records, walRecord{
+			State:     entry.status.State,
+			Ticket:    ticket,
+			Namespace: entry.namespace,
+			GasPrice:  entry.gasPrice,
+			Caller:    entry.caller,
+			Blobs:     entry.blobs,
+			IDs:       entry.status.IDs,
+			Progress:  entry.progress,
+			Error:     entry.status.Error,
+			Attempts:  entry.status.Attempts,
+			Created:   entry.status.Created,
+			Updated:   entry.status.Updated,
+		})
+	}
+	return q.wal.rewrite(records)
+}
+
+// broadcast wakes up watchers. Must be called with q.mu held.
+func (q *Queue) broadcast() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// notify wakes up the worker.
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run submits pending blobs in order until ctx is done.
+func (q *Queue) run(ctx context.Context) {
+	defer close(q.done)
+	for {
+		q.mu.Lock()
+		var entry *queueEntry
+		if len(q.pending) > 0 {
+			entry = q.entries[q.pending[0]]
+		}
+		q.mu.Unlock()
+		if entry == nil {
+			select {
+			case <-q.wake:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := q.waitResumed(ctx); err != nil {
+			return
+		}
+
+		submitCtx := ContextWithCaller(ctx, entry.caller)
+		var ids []da.ID
+		var err error
+		if entry.progress != nil {
+			ids, err = q.CelestiaDA.ResumeSubmit(submitCtx, entry.blobs, entry.gasPrice, entry.namespace, *entry.progress)
+		} else {
+			ids, err = q.CelestiaDA.Submit(submitCtx, entry.blobs, entry.gasPrice, entry.namespace)
+		}
+		if ctx.Err() != nil {
+			// blobs included before the cancellation aren't submitted again after a restart
+			q.progressed(entry, err)
+			return
+		}
+		if errors.Is(err, ErrPaused) {
+			// paused after waiting, which isn't an attempt
+			continue
+		}
+		if errors.Is(err, ErrNotFinal) && len(ids) > 0 {
+			// the blobs were included, so submitting them again would include them twice
+			log.Println("queued submission not confirmed", "ticket", entry.status.Ticket, "error", err)
+			err = nil
+		}
+		result := "success"
+		if err != nil {
+			result = "error"
+		}
+		daMetrics.queueAttempts.Add(ctx, 1, metric.WithAttributes(attribute.String("result", result)))
+
+		delay := q.attempted(entry, ids, err)
+		if delay == 0 {
+			continue
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// attempted records the result of a submission attempt, and returns the delay before the next
+// attempt, or 0 if the submission is final.
+func (q *Queue) attempted(entry *queueEntry, ids []da.ID, err error) time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	defer q.broadcast()
+
+	status := &entry.status
+	status.Attempts++
+	status.Updated = time.Now().UTC()
+	if err != nil {
+		status.Error = err.Error()
+		log.Println("queued submission failed", "ticket", status.Ticket, "attempt", status.Attempts, "error", err)
+		q.recordProgress(entry, err)
+		if !permanentError(err) && (q.config.MaxAttempts == 0 || status.Attempts < q.config.MaxAttempts) {
+			delay := q.config.RetryInterval
+			for i := 1; i < status.Attempts && delay < q.config.MaxRetryInterval; i++ {
+				delay *= 2
+			}
+			return min(delay, q.config.MaxRetryInterval)
+		}
+	}
+
+	record := walRecord{
+		State:    SubmissionCompleted,
+		Ticket:   status.Ticket,
+		IDs:      ids,
+		Attempts: status.Attempts,
+		Created:  status.Created,
+		Updated:  status.Updated,
+	}
+	if err != nil {
+		record.State, record.Error = SubmissionFailed, err.Error()
+		if entry.progress != nil {
+			record.IDs = entry.progress.IDs
+		}
+	}
+	if err := q.wal.append(record); err != nil {
+		// the submission is resubmitted after a restart
+		log.Println("failed to persist queued submission result", "ticket", status.Ticket, "error", err)
+	}
+	q.replay(record)
+	daMetrics.queuePending.Add(context.Background(), -1)
+	if q.wal.records > 2*(len(q.pending)+len(q.finished))+1024 {
+		if err := q.compact(); err != nil {
+			log.Println("failed to compact queue log", "error", err)
+		}
+	}
+	return 0
+}
+
+// permanentErrors fail a queued submission right away, as further attempts fail the same way and
+// would hold up the submissions queued after it.
+var permanentErrors = []error{
+	ErrBlobTooLarge,
+	ErrBlobRejected,
+	ErrInvalidNamespace,
+	ErrUnsupportedNamespaceVersion,
+	ErrReservedNamespace,
+	ErrParityNamespace,
+	ErrTailPaddingNamespace,
+}
+
+func permanentError(err error) bool {
+	for _, permanent := range permanentErrors {
+		if errors.Is(err, permanent) {
+			return true
+		}
+	}
+	return false
+}
+
+// progressed records the progress of an attempt that failed with err, if any.
+func (q *Queue) progressed(entry *queueEntry, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.recordProgress(entry, err)
+}
+
+// recordProgress records and persists the progress of an attempt that failed with err, if any. Must
+// be called with q.mu held.
+func (q *Queue) recordProgress(entry *queueEntry, err error) {
+	var submitErr *SubmitError
+	if !errors.As(err, &submitErr) {
+		return
+	}
+	entry.progress = &submitErr.Progress
+	record := walRecord{
+		State:    SubmissionPending,
+		Ticket:   entry.status.Ticket,
+		Progress: entry.progress,
+		Error:    entry.status.Error,
+		Attempts: entry.status.Attempts,
+		Created:  entry.status.Created,
+		Updated:  entry.status.Updated,
+	}
+	if err := q.wal.append(record); err != nil {
+		log.Println("failed to persist queued submission progress", "ticket", entry.status.Ticket, "error", err)
+	}
+}
+
+var _ da.DA = &Queue{}
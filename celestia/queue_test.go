@@ -0,0 +1,279 @@
+package celestia
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testQueueConfig(t *testing.T) QueueConfig {
+	config := DefaultQueueConfig(t.TempDir())
+	config.RetryInterval = 10 * time.Millisecond
+	config.MaxRetryInterval = 10 * time.Millisecond
+	return config
+}
+
+// waitFinal waits until the submission with the given ticket is final.
+func waitFinal(t *testing.T, q *Queue, ticket string) SubmissionStatus {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	updates, err := q.Watch(ctx, ticket)
+	require.NoError(t, err)
+	var last SubmissionStatus
+	for st := range updates {
+		last = st
+	}
+	require.True(t, last.Final(), "submission %s is not final", ticket)
+	return last
+}
+
+func TestQueue(t *testing.T) {
+	m := setup(t)
+	defer teardown(m)
+	ctx := context.TODO()
+
+	q, err := NewQueue(&m.CelestiaDA, testQueueConfig(t))
+	require.NoError(t, err)
+	defer q.Close()
+
+	data := []Blob{[]byte("first"), []byte("second")}
+	ids, err := q.Submit(ctx, data, -1, nil)
+	require.NoError(t, err)
+	require.Len(t, ids, 2)
+
+	ticket, index, ok := TicketFromID(ids[1])
+	require.True(t, ok)
+	assert.Equal(t, 1, index)
+
+	st := waitFinal(t, q, ticket)
+	assert.Equal(t, SubmissionCompleted, st.State)
+	assert.Equal(t, 1, st.Attempts)
+	require.Len(t, st.IDs, 2)
+
+	// pending IDs resolve to the final IDs
+	blobs, err := q.Get(ctx, ids, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, data, blobs)
+	blobs, err = q.Get(ctx, st.IDs, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, data, blobs)
+
+	_, err = q.Status("00")
+	assert.ErrorIs(t, err, ErrUnknownTicket)
+	_, _, ok = TicketFromID(st.IDs[0])
+	assert.False(t, ok)
+}
+
+func TestQueue_Restart(t *testing.T) {
+	m := setup(t)
+	ctx := context.TODO()
+	config := testQueueConfig(t)
+
+	// the node is unreachable, so the submission stays pending
+	unreachable := m.CelestiaDA
+	teardown(m)
+	q, err := NewQueue(&unreachable, config)
+	require.NoError(t, err)
+	ids, err := q.Submit(ctx, []Blob{[]byte("durable")}, -1, nil)
+	require.NoError(t, err)
+	ticket, _, _ := TicketFromID(ids[0])
+
+	time.Sleep(50 * time.Millisecond)
+	st, err := q.Status(ticket)
+	require.NoError(t, err)
+	assert.Equal(t, SubmissionPending, st.State)
+	assert.NotEmpty(t, st.Error)
+	_, err = q.Get(ctx, ids, nil)
+	assert.ErrorIs(t, err, ErrSubmissionPending)
+	require.NoError(t, q.Close())
+
+	// a torn record at the end of the log is discarded
+	f, err := os.OpenFile(filepath.Join(config.Dir, walFile), os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0, 0, 1, 0, 42})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	// the pending submission is resumed after a restart
+	m = setup(t)
+	defer teardown(m)
+	q, err = NewQueue(&m.CelestiaDA, config)
+	require.NoError(t, err)
+	st2 := waitFinal(t, q, ticket)
+	assert.Equal(t, SubmissionCompleted, st2.State)
+	blobs, err := q.Get(ctx, ids, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []Blob{[]byte("durable")}, blobs)
+	require.NoError(t, q.Close())
+
+	// finished submissions are kept for status queries
+	q, err = NewQueue(&m.CelestiaDA, config)
+	require.NoError(t, err)
+	defer q.Close()
+	st3, err := q.Status(ticket)
+	require.NoError(t, err)
+	assert.Equal(t, st2.IDs, st3.IDs)
+}
+
+func TestQueue_CorruptLog(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, walFile)
+	w, _, err := openWAL(dir)
+	require.NoError(t, err)
+	require.NoError(t, w.append(walRecord{State: SubmissionPending, Ticket: "first"}, walRecord{State: SubmissionPending, Ticket: "second"}))
+	require.NoError(t, w.close())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// a corrupt last record is a torn record, and is discarded
+	corrupt := append([]byte{}, data...)
+	corrupt[len(corrupt)-2] ^= 0xff
+	require.NoError(t, os.WriteFile(path, corrupt, 0o600))
+	w, records, err := openWAL(dir)
+	require.NoError(t, err)
+	require.NoError(t, w.close())
+	require.Len(t, records, 1)
+	assert.Equal(t, "first", records[0].Ticket)
+
+	// a corrupt record followed by others isn't truncated
+	corrupt = append([]byte{}, data...)
+	corrupt[10] ^= 0xff
+	require.NoError(t, os.WriteFile(path, corrupt, 0o600))
+	_, _, err = openWAL(dir)
+	assert.Error(t, err)
+	stored, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, corrupt, stored)
+}
+
+func TestQueue_MaxAttempts(t *testing.T) {
+	m := setup(t)
+	ctx := context.TODO()
+	unreachable := m.CelestiaDA
+	teardown(m)
+
+	config := testQueueConfig(t)
+	config.MaxAttempts = 2
+	q, err := NewQueue(&unreachable, config)
+	require.NoError(t, err)
+	defer q.Close()
+
+	ids, err := q.Submit(ctx, []Blob{[]byte("lost")}, -1, nil)
+	require.NoError(t, err)
+	ticket, _, _ := TicketFromID(ids[0])
+	st := waitFinal(t, q, ticket)
+	assert.Equal(t, SubmissionFailed, st.State)
+	assert.Equal(t, 2, st.Attempts)
+	assert.NotEmpty(t, st.Error)
+
+	_, err = q.Get(ctx, ids, nil)
+	assert.ErrorIs(t, err, ErrSubmissionFailed)
+
+	_, err = q.Submit(ctx, []Blob{[]byte("invalid namespace")}, -1, make([]byte, 11))
+	assert.ErrorIs(t, err, ErrInvalidNamespace)
+}
+
+func TestQueue_Permanent(t *testing.T) {
+	m := setup(t)
+	defer teardown(m)
+	ctx := context.TODO()
+
+	q, err := NewQueue(&m.CelestiaDA, testQueueConfig(t))
+	require.NoError(t, err)
+	defer q.Close()
+
+	// blobs that can't be submitted without chunking are refused before they are queued
+	_, err = q.Submit(ctx, []Blob{make([]byte, DefaultMaxBytes+1)}, -1, nil)
+	assert.ErrorIs(t, err, ErrBlobTooLarge)
+	assert.Empty(t, q.Pending())
+
+	// a rejected submission fails without further attempts, and doesn't hold up the next one
+	m.s.state.failSubmit(submitFailure{err: errors.New("broadcasting tx: cannot use zero blob size")})
+	rejected, err := q.Submit(ctx, []Blob{[]byte("rejected")}, -1, nil)
+	require.NoError(t, err)
+	next, err := q.Submit(ctx, []Blob{[]byte("next")}, -1, nil)
+	require.NoError(t, err)
+
+	ticket, _, _ := TicketFromID(rejected[0])
+	st := waitFinal(t, q, ticket)
+	assert.Equal(t, SubmissionFailed, st.State)
+	assert.Equal(t, 1, st.Attempts)
+	ticket, _, _ = TicketFromID(next[0])
+	st = waitFinal(t, q, ticket)
+	assert.Equal(t, SubmissionCompleted, st.State)
+}
+
+func TestQueue_Partial(t *testing.T) {
+	m := setup(t)
+	defer teardown(m)
+	ctx := context.TODO()
+
+	// the blobs are submitted in two transactions
+	failed := errors.New("failed")
+
+	// submit queues blobs submitted in two transactions with a queue on config, and returns the
+	// final status once the second transaction failed with failure and the submission was resumed
+	submit := func(t *testing.T, config QueueConfig, failure submitFailure, restart bool) SubmissionStatus {
+		var data []Blob
+		for i := byte(1); i <= 3; i++ {
+			data = append(data, append([]byte(t.Name()), bytes.Repeat([]byte{i}, DefaultMaxBytes/3+1)...))
+		}
+		m.s.state.failSubmit(submitFailure{}, failure)
+		start := m.s.blob.currentHeight()
+		retry := config
+		if restart {
+			retry.RetryInterval, retry.MaxRetryInterval = time.Hour, time.Hour
+		}
+		q, err := NewQueue(&m.CelestiaDA, retry)
+		require.NoError(t, err)
+		ids, err := q.Submit(ctx, data, -1, nil)
+		require.NoError(t, err)
+		ticket, _, _ := TicketFromID(ids[0])
+		if restart {
+			require.Eventually(t, func() bool {
+				st, err := q.Status(ticket)
+				return err == nil && st.Attempts == 1
+			}, 10*time.Second, 10*time.Millisecond)
+			require.NoError(t, q.Close())
+			q, err = NewQueue(&m.CelestiaDA, config)
+			require.NoError(t, err)
+		}
+		defer q.Close()
+
+		st := waitFinal(t, q, ticket)
+		require.Equal(t, SubmissionCompleted, st.State)
+		assert.Equal(t, 2, st.Attempts)
+		// the first transaction isn't submitted again
+		assert.Equal(t, start+2, m.s.blob.currentHeight())
+		var heights []uint64
+		for _, id := range st.IDs {
+			height, _ := splitID(id)
+			heights = append(heights, height)
+		}
+		assert.Equal(t, []uint64{start + 1, start + 1, start + 2}, heights)
+		blobs, err := q.Get(ctx, ids, nil)
+		require.NoError(t, err)
+		assert.Equal(t, data, blobs)
+		return st
+	}
+
+	t.Run("Rejected", func(t *testing.T) {
+		submit(t, testQueueConfig(t), submitFailure{err: failed}, false)
+	})
+
+	t.Run("Ambiguous", func(t *testing.T) {
+		// the failed transaction was included, so it is found instead of being submitted again
+		submit(t, testQueueConfig(t), submitFailure{err: failed, included: true}, false)
+	})
+
+	t.Run("Restart", func(t *testing.T) {
+		submit(t, testQueueConfig(t), submitFailure{err: failed}, true)
+	})
+}
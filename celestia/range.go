@@ -0,0 +1,123 @@
+package celestia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rollkit/go-da"
+)
+
+// ErrInvalidRange is returned by range queries with an empty range or a range starting at height 0.
+var ErrInvalidRange = errors.New("invalid height range")
+
+const (
+	// rangeConcurrency is the number of heights fetched concurrently by range queries.
+	rangeConcurrency = 16
+	// maxRangeHeights is the number of heights scanned by a single range query.
+	maxRangeHeights = 1000
+	// maxRangeBytes bounds the size of the blobs returned by a single range query, so the JSON
+	// encoded response fits into the default gRPC message size limit. At least one height is always
+	// returned.
+	maxRangeBytes = 2 << 20
+)
+
+// RangeResult holds the blobs in a namespace over a range of heights.
+type RangeResult struct {
+	// Heights holds the heights with blobs in the namespace, in ascending order.
+	Heights []*HeightBlobs `json:"heights"`
+	// Next is the height to continue the query from, or 0 if the whole range was returned. A range
+	// that extends beyond the current head is returned up to the head, with Next set to the height
+	// after it.
+	Next uint64 `json:"next,omitempty"`
+}
+
+// GetIDsRange returns the IDs of all blobs in the namespace at heights from to to, inclusive.
+//
+// Up to 1000 heights are returned at once, continue from RangeResult.Next to get the remaining ones.
+func (c *CelestiaDA) GetIDsRange(ctx context.Context, from, to uint64, ns da.Namespace) (*RangeResult, error) {
+	return c.getRange(ctx, from, to, ns, false)
+}
+
+// GetAllRange returns the IDs and blobs of all blobs in the namespace at heights from to to, inclusive.
+//
+// Up to 1000 heights and about 2 MiB of blobs are returned at once, continue from RangeResult.Next
+// to get the remaining ones.
+func (c *CelestiaDA) GetAllRange(ctx context.Context, from, to uint64, ns da.Namespace) (*RangeResult, error) {
+	return c.getRange(ctx, from, to, ns, true)
+}
+
+func (c *CelestiaDA) getRange(ctx context.Context, from, to uint64, ns da.Namespace, withBlobs bool) (*RangeResult, error) {
+	namespace, err := c.defaultNamespace(ns)
+	if err != nil {
+		return nil, err
+	}
+	if from == 0 || from > to {
+		return nil, fmt.Errorf("%w: %d to %d", ErrInvalidRange, from, to)
+	}
+
+	head, err := c.client.Header.LocalHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	last := min(to, from+maxRangeHeights-1, head.Height())
+	if last < from {
+		// nothing available yet
+		return &RangeResult{Next: from}, nil
+	}
+
+	results := make([]*HeightBlobs, last-from+1)
+	// fetched is the size of the blobs fetched so far, once it exceeds maxRangeBytes the fetched
+	// heights fill the response and the remaining heights aren't fetched
+	var fetched atomic.Int64
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(rangeConcurrency)
+	for height := from; height <= last && fetched.Load() <= maxRangeBytes; height++ {
+		height := height
+		g.Go(func() error {
+			if fetched.Load() > maxRangeBytes {
+				// the fetch waited for others that filled the response
+				return nil
+			}
+			result, err := c.heightBlobs(gctx, height, namespace, withBlobs)
+			if err != nil {
+				return fmt.Errorf("height %d: %w", height, err)
+			}
+			for _, b := range result.Blobs {
+				fetched.Add(int64(len(b)))
+			}
+			results[height-from] = result
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	out := &RangeResult{Heights: []*HeightBlobs{}}
+	size := 0
+	for i, result := range results {
+		if result == nil {
+			// not fetched
+			out.Next = from + uint64(i)
+			return out, nil
+		}
+		for _, b := range result.Blobs {
+			size += len(b)
+		}
+		if size > maxRangeBytes && len(out.Heights) > 0 {
+			out.Next = result.Height
+			return out, nil
+		}
+		if len(result.IDs) > 0 {
+			out.Heights = append(out.Heights, result)
+		}
+	}
+	if last < to {
+		out.Next = last + 1
+	}
+	return out, nil
+}
@@ -0,0 +1,88 @@
+package celestia
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCelestiaDA_GetRange(t *testing.T) {
+	m := setup(t)
+	defer teardown(m)
+	ctx := context.TODO()
+
+	var ids [][]ID
+	for _, data := range []Blob{[]byte("first"), []byte("second"), []byte("third")} {
+		submitted, err := m.Submit(ctx, []Blob{data}, -1, nil)
+		require.NoError(t, err)
+		ids = append(ids, submitted)
+	}
+
+	result, err := m.GetIDsRange(ctx, 1, 3, nil)
+	require.NoError(t, err)
+	require.Len(t, result.Heights, 3)
+	assert.Zero(t, result.Next)
+	for i, h := range result.Heights {
+		assert.Equal(t, uint64(i+1), h.Height)
+		assert.Equal(t, ids[i], h.IDs)
+		assert.Empty(t, h.Blobs)
+	}
+
+	// a range beyond the head continues after the head
+	result, err = m.GetAllRange(ctx, 2, 10, nil)
+	require.NoError(t, err)
+	require.Len(t, result.Heights, 2)
+	assert.Equal(t, []Blob{[]byte("second")}, result.Heights[0].Blobs)
+	assert.Equal(t, uint64(4), result.Next)
+	result, err = m.GetAllRange(ctx, 4, 10, nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.Heights)
+	assert.Equal(t, uint64(4), result.Next)
+
+	_, err = m.GetIDsRange(ctx, 3, 2, nil)
+	assert.Error(t, err)
+	_, err = m.GetIDsRange(ctx, 0, 2, nil)
+	assert.Error(t, err)
+}
+
+func TestCelestiaDA_GetAllRange_MaxBytes(t *testing.T) {
+	m := setup(t)
+	defer teardown(m)
+	ctx := context.TODO()
+
+	large := bytes.Repeat([]byte{0x01}, maxRangeBytes/2+1)
+	_, err := m.Submit(ctx, []Blob{large}, -1, nil)
+	require.NoError(t, err)
+	// the same blob is stored at the following heights, as submitting each is slow
+	stored, err := m.s.blob.stored(1)
+	require.NoError(t, err)
+	const count = rangeConcurrency + 2
+	for i := 1; i < count; i++ {
+		_, err := m.s.blob.store(stored)
+		require.NoError(t, err)
+	}
+
+	// the heights beyond the first ones filling the response aren't fetched
+	calls := m.s.blob.getAllCalls()
+	result, err := m.GetAllRange(ctx, 1, count, nil)
+	require.NoError(t, err)
+	assert.Len(t, result.Heights, 1)
+	assert.Equal(t, uint64(2), result.Next)
+	assert.Less(t, m.s.blob.getAllCalls()-calls, count)
+
+	var heights []uint64
+	for from := uint64(1); from != 0; {
+		result, err := m.GetAllRange(ctx, from, 3, nil)
+		require.NoError(t, err)
+		require.NotEmpty(t, result.Heights)
+		for _, h := range result.Heights {
+			heights = append(heights, h.Height)
+			assert.Equal(t, []Blob{large}, h.Blobs)
+		}
+		from = result.Next
+	}
+	assert.Equal(t, []uint64{1, 2, 3}, heights)
+}
@@ -0,0 +1,175 @@
+package celestia
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/celestiaorg/celestia-app/pkg/shares"
+	"github.com/celestiaorg/celestia-node/blob"
+	"github.com/celestiaorg/celestia-node/share"
+
+	"github.com/rollkit/go-da"
+)
+
+// Receipt describes a transaction submitted by Submit.
+type Receipt struct {
+	Height uint64 `json:"height"`
+	TxHash string `json:"tx_hash"`
+	// Fee is the fee paid for the transaction, in utia.
+	Fee       uint64 `json:"fee"`
+	GasWanted uint64 `json:"gas_wanted"`
+	GasUsed   uint64 `json:"gas_used"`
+	// Blobs holds the blobs included by the transaction, in order.
+	Blobs []ReceiptBlob `json:"blobs"`
+}
+
+// ReceiptBlob describes a blob included by a transaction.
+type ReceiptBlob struct {
+	ID da.ID `json:"id"`
+	// Shares is the range of shares occupied by the blob in the data square, nil if the node
+	// didn't report it.
+	Shares *ShareRange `json:"shares,omitempty"`
+}
+
+// ShareRange is a range of share indices in the data square.
+type ShareRange struct {
+	Start int `json:"start"`
+	// End is the index following the last share of the range.
+	End int `json:"end"`
+}
+
+// SubmitResult holds the result of SubmitWithReceipts.
+type SubmitResult struct {
+	// IDs holds the IDs returned by Submit.
+	IDs []da.ID `json:"ids"`
+	// Receipts holds a receipt per submitted transaction, including the transactions of chunks with
+	// WithChunking, in order of submission.
+	Receipts []*Receipt `json:"receipts"`
+	// NotFinal is set if the blobs were included, but not confirmed in time with WithFinality.
+	NotFinal bool `json:"not_final,omitempty"`
+}
+
+// SubmitError is returned by Submit and SubmitWithReceipts if a submission failed after some of its
+// blobs or chunks were included, or after sending a transaction that may have been included
+// regardless, along with the IDs of the included blobs. Retrying the submission as a whole would
+// pay for the included blobs again, ResumeSubmit only submits the remaining ones.
+type SubmitError struct {
+	// Progress describes the part of the submission that was, or may have been, included.
+	Progress SubmitProgress
+	// Receipts holds the receipts of the included transactions.
+	Receipts []*Receipt
+	// Blobs is the number of blobs of the submission.
+	Blobs int
+	// Err is the error that stopped the submission.
+	Err error
+}
+
+func (e *SubmitError) Error() string {
+	if len(e.Progress.IDs) == 0 && len(e.Progress.Chunks) == 0 {
+		return fmt.Sprintf("submission may have been included: %v", e.Err)
+	}
+	return fmt.Sprintf("submitted %d of %d blobs: %v", len(e.Progress.IDs), e.Blobs, e.Err)
+}
+
+func (e *SubmitError) Unwrap() error {
+	return e.Err
+}
+
+// SubmitProgress is the part of a failed submission that was, or may have been, included.
+type SubmitProgress struct {
+	// IDs holds the IDs of the leading blobs of the submission that were included, in order.
+	IDs []da.ID `json:"ids,omitempty"`
+	// Chunks holds the IDs of the included chunks of blobs submitted as chunks with WithChunking, by
+	// the index of the blob.
+	Chunks map[int][]da.ID `json:"chunks,omitempty"`
+	// Ambiguous is set if the failed transaction was sent to the node, and may have been included
+	// although the node reported an error, e.g. a timeout or a connection lost before the response.
+	Ambiguous bool `json:"ambiguous,omitempty"`
+	// Height is the local head of the node when the ambiguous transaction failed, 0 if unknown.
+	Height uint64 `json:"height,omitempty"`
+}
+
+// broadcastError is the error of a transaction that was sent to the node, see
+// SubmitProgress.Ambiguous.
+type broadcastError struct {
+	err error
+}
+
+func (e *broadcastError) Error() string {
+	return e.err.Error()
+}
+
+func (e *broadcastError) Unwrap() error {
+	return e.err
+}
+
+// SubmitWithReceipts submits the blobs like Submit, and additionally returns a receipt per
+// transaction with the transaction hash, the fee and gas paid, and the shares occupied by each blob.
+//
+// With WithFinality, the result is returned with NotFinal set along with ErrNotFinal if the blobs
+// weren't confirmed in time. If the submission failed after blobs were, or may have been, included, the result holds
+// the IDs and receipts of the included ones, and the error is a *SubmitError.
+func (c *CelestiaDA) SubmitWithReceipts(ctx context.Context, daBlobs []da.Blob, gasPrice float64, ns da.Namespace) (*SubmitResult, error) {
+	return c.submit(ctx, daBlobs, gasPrice, ns, true, nil)
+}
+
+// payForBlobs submits blobs in a single transaction, and returns its receipt without share ranges.
+func (c *CelestiaDA) payForBlobs(ctx context.Context, blobs []*blob.Blob, gasPrice float64) (*Receipt, error) {
+	sizes := make([]uint32, len(blobs))
+	for i, b := range blobs {
+		sizes[i] = uint32(len(b.Data))
+	}
+	gas, fee := estimateFee(sizes, gasPrice)
+	if err := c.checkFunds(fee); err != nil {
+		return nil, err
+	}
+	resp, err := c.client.State.SubmitPayForBlob(ctx, sdkmath.NewIntFromUint64(fee), gas, blobs)
+	if err != nil {
+		if rejected := rejectionError(err); rejected != nil {
+			// the transaction wasn't broadcast
+			return nil, rejected
+		}
+		return nil, &broadcastError{err}
+	}
+	if c.balance != nil {
+		c.balance.spend(fee)
+	}
+	receipt := &Receipt{
+		Height:    uint64(resp.Height),
+		TxHash:    resp.TxHash,
+		Fee:       fee,
+		GasWanted: gas,
+		GasUsed:   uint64(resp.GasUsed),
+		Blobs:     make([]ReceiptBlob, len(blobs)),
+	}
+	for i, b := range blobs {
+		receipt.Blobs[i].ID = makeID(receipt.Height, b.Commitment)
+	}
+	return receipt, nil
+}
+
+// resolveShares sets the share ranges of the blobs of the receipt, as reported by the node once it
+// synced the inclusion height. Failures are logged, as the blobs were submitted regardless.
+func (c *CelestiaDA) resolveShares(ctx context.Context, receipt *Receipt, ns share.Namespace) {
+	if _, err := c.client.Header.WaitForHeight(ctx, receipt.Height); err != nil {
+		log.Println("failed to resolve shares of submitted blobs", "height", receipt.Height, "error", err)
+		return
+	}
+	for i := range receipt.Blobs {
+		_, commitment := splitID(receipt.Blobs[i].ID)
+		b, err := c.client.Blob.Get(ctx, receipt.Height, ns, commitment)
+		if err != nil {
+			log.Println("failed to resolve shares of submitted blob", "height", receipt.Height, "error", err)
+			continue
+		}
+		if b.Index() < 0 {
+			continue
+		}
+		receipt.Blobs[i].Shares = &ShareRange{
+			Start: b.Index(),
+			End:   b.Index() + shares.SparseSharesNeeded(uint32(len(b.Data))),
+		}
+	}
+}
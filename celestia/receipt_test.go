@@ -0,0 +1,75 @@
+package celestia
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/celestiaorg/celestia-app/x/blob/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCelestiaDA_SubmitWithReceipts(t *testing.T) {
+	m := setup(t)
+	defer teardown(m)
+	ctx := context.TODO()
+
+	indexed, err := NewCelestiaDAWithOptions(m.client, m.namespace, -1, ctx, WithIndex(t.TempDir()))
+	require.NoError(t, err)
+	defer indexed.Close()
+
+	large := bytes.Repeat([]byte("x"), 600)
+	result, err := indexed.SubmitWithReceipts(ctx, []Blob{[]byte("first"), large}, 0.5, nil)
+	require.NoError(t, err)
+	require.Len(t, result.IDs, 2)
+	require.Len(t, result.Receipts, 1)
+
+	receipt := result.Receipts[0]
+	height, _ := splitID(result.IDs[0])
+	gas := types.DefaultEstimateGas([]uint32{uint32(len("first")), uint32(len(large))})
+	assert.Equal(t, height, receipt.Height)
+	assert.Len(t, receipt.TxHash, 64)
+	assert.Equal(t, gas, receipt.GasWanted)
+	assert.Equal(t, gas/2, receipt.GasUsed)
+	assert.Equal(t, (gas+1)/2, receipt.Fee)
+	require.Len(t, receipt.Blobs, 2)
+	assert.Equal(t, result.IDs[0], receipt.Blobs[0].ID)
+	assert.Equal(t, &ShareRange{Start: 1, End: 2}, receipt.Blobs[0].Shares)
+	// the large blob spans two shares
+	assert.Equal(t, &ShareRange{Start: 2, End: 4}, receipt.Blobs[1].Shares)
+
+	_, commitment := splitID(result.IDs[1])
+	entries, err := indexed.LookupSubmission(commitment)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, receipt.TxHash, entries[0].TxHash)
+	assert.Equal(t, receipt.Fee, entries[0].Fee)
+	assert.Equal(t, receipt.GasUsed, entries[0].GasUsed)
+	assert.Equal(t, receipt.Blobs[1].Shares, entries[0].Shares)
+
+	// Submit indexes receipts without share ranges
+	ids, err := indexed.Submit(ctx, []Blob{[]byte("plain")}, -1, nil)
+	require.NoError(t, err)
+	_, commitment = splitID(ids[0])
+	entries, err = indexed.LookupSubmission(commitment)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.NotEmpty(t, entries[0].TxHash)
+	assert.Nil(t, entries[0].Shares)
+
+	t.Run("Chunks", func(t *testing.T) {
+		chunked, err := NewCelestiaDAWithOptions(m.client, m.namespace, -1, ctx, WithChunking(minChunkSize))
+		require.NoError(t, err)
+		result, err := chunked.SubmitWithReceipts(ctx, []Blob{bytes.Repeat([]byte("y"), 2*minChunkSize)}, -1, nil)
+		require.NoError(t, err)
+		require.Len(t, result.IDs, 1)
+		// three chunks and the manifest
+		require.Len(t, result.Receipts, 4)
+		assert.Equal(t, result.IDs[0], result.Receipts[3].Blobs[0].ID)
+		for _, receipt := range result.Receipts {
+			assert.NotEmpty(t, receipt.TxHash)
+			assert.NotNil(t, receipt.Blobs[0].Shares)
+		}
+	})
+}
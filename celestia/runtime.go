@@ -0,0 +1,133 @@
+package celestia
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+// ErrPaused is returned by Submit while submissions are paused.
+var ErrPaused = errors.New("submissions are paused")
+
+// runtimeState holds the settings of a CelestiaDA that can be changed while it's serving.
+type runtimeState struct {
+	mu       sync.Mutex
+	gasPrice float64
+	// resumed is non-nil while submissions are paused, and closed when they are resumed.
+	resumed chan struct{}
+}
+
+// GasPrice returns the gas price of submissions with a negative gas price, negative for the default
+// minimum gas price.
+func (c *CelestiaDA) GasPrice() float64 {
+	c.runtime.mu.Lock()
+	defer c.runtime.mu.Unlock()
+	return c.runtime.gasPrice
+}
+
+// SetGasPrice replaces the gas price of submissions with a negative gas price.
+func (c *CelestiaDA) SetGasPrice(gasPrice float64) {
+	c.runtime.mu.Lock()
+	defer c.runtime.mu.Unlock()
+	log.Println("changing default gas price", "from", c.runtime.gasPrice, "to", gasPrice)
+	c.runtime.gasPrice = gasPrice
+}
+
+// Pause makes Submit fail with ErrPaused until Resume is called. Queued submissions are kept, and
+// submitted once resumed.
+func (c *CelestiaDA) Pause() {
+	c.runtime.mu.Lock()
+	defer c.runtime.mu.Unlock()
+	if c.runtime.resumed == nil {
+		log.Println("pausing submissions")
+		c.runtime.resumed = make(chan struct{})
+	}
+}
+
+// Resume resumes submissions paused by Pause.
+func (c *CelestiaDA) Resume() {
+	c.runtime.mu.Lock()
+	defer c.runtime.mu.Unlock()
+	if c.runtime.resumed != nil {
+		log.Println("resuming submissions")
+		close(c.runtime.resumed)
+		c.runtime.resumed = nil
+	}
+}
+
+// Paused returns true while submissions are paused.
+func (c *CelestiaDA) Paused() bool {
+	c.runtime.mu.Lock()
+	defer c.runtime.mu.Unlock()
+	return c.runtime.resumed != nil
+}
+
+// waitResumed waits until submissions aren't paused or ctx is done.
+func (c *CelestiaDA) waitResumed(ctx context.Context) error {
+	c.runtime.mu.Lock()
+	resumed := c.runtime.resumed
+	c.runtime.mu.Unlock()
+	if resumed == nil {
+		return nil
+	}
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Config describes the configuration of a CelestiaDA.
+type Config struct {
+	Namespace share.Namespace `json:"namespace"`
+	// GasPrice is the gas price of submissions with a negative gas price, negative for the default
+	// minimum gas price.
+	GasPrice float64 `json:"gas_price"`
+	Paused   bool    `json:"paused"`
+	// Compression is the compression algorithm, empty if compression is disabled.
+	Compression string          `json:"compression,omitempty"`
+	Encryption  bool            `json:"encryption"`
+	Chunking    bool            `json:"chunking"`
+	ChunkSize   uint64          `json:"chunk_size,omitempty"`
+	Cache       *CacheConfig    `json:"cache,omitempty"`
+	Index       bool            `json:"index"`
+	Finality    *FinalityConfig `json:"finality,omitempty"`
+	Balance     *BalanceConfig  `json:"balance,omitempty"`
+}
+
+// Config returns the current configuration.
+func (c *CelestiaDA) Config() *Config {
+	config := &Config{
+		Namespace: c.namespace,
+		GasPrice:  c.GasPrice(),
+		Paused:    c.Paused(),
+		Chunking:  c.chunking,
+		ChunkSize: c.chunkSize,
+		Index:     c.index != nil,
+	}
+	for _, t := range c.transforms {
+		switch t := t.(type) {
+		case *compressor:
+			config.Compression = t.algorithm.String()
+		case *encryptor:
+			config.Encryption = true
+		}
+	}
+	if c.cache != nil {
+		cache := c.cache.config
+		config.Cache = &cache
+	}
+	if c.finality != nil {
+		finality := *c.finality
+		config.Finality = &finality
+	}
+	if c.balance != nil {
+		balance := c.balance.config
+		config.Balance = &balance
+	}
+	return config
+}
@@ -0,0 +1,63 @@
+package celestia
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCelestiaDA_Runtime(t *testing.T) {
+	m := setup(t)
+	defer teardown(m)
+	ctx := context.TODO()
+
+	config := m.Config()
+	assert.Equal(t, m.namespace, config.Namespace)
+	assert.Equal(t, float64(-1), config.GasPrice)
+	assert.False(t, config.Paused)
+	assert.Nil(t, config.Cache)
+
+	// the gas price of the service applies to submissions without a gas price
+	m.SetGasPrice(0.01)
+	assert.Equal(t, 0.01, m.Config().GasPrice)
+	result, err := m.SubmitWithReceipts(ctx, []Blob{[]byte("blob")}, -1, nil)
+	require.NoError(t, err)
+	receipt := result.Receipts[0]
+	assert.Equal(t, uint64(math.Ceil(0.01*float64(receipt.GasWanted))), receipt.Fee)
+	result, err = m.SubmitWithReceipts(ctx, []Blob{[]byte("blob")}, 0.5, nil)
+	require.NoError(t, err)
+	receipt = result.Receipts[0]
+	assert.Equal(t, uint64(math.Ceil(0.5*float64(receipt.GasWanted))), receipt.Fee)
+
+	q, err := NewQueue(&m.CelestiaDA, testQueueConfig(t))
+	require.NoError(t, err)
+	defer q.Close()
+
+	m.Pause()
+	assert.True(t, m.Config().Paused)
+	height := m.s.blob.currentHeight()
+	_, err = m.Submit(ctx, []Blob{[]byte("blob")}, -1, nil)
+	assert.ErrorIs(t, err, ErrPaused)
+
+	// queued submissions are held until resumed
+	ids, err := q.Submit(ctx, []Blob{[]byte("queued")}, -1, nil)
+	require.NoError(t, err)
+	ticket, _, _ := TicketFromID(ids[0])
+	time.Sleep(50 * time.Millisecond)
+	pending := q.Pending()
+	require.Len(t, pending, 1)
+	assert.Equal(t, ticket, pending[0].Ticket)
+	assert.Zero(t, pending[0].Attempts)
+	assert.Equal(t, height, m.s.blob.currentHeight(), "paused submissions aren't attempted")
+
+	m.Resume()
+	assert.False(t, m.Paused())
+	st := waitFinal(t, q, ticket)
+	assert.Equal(t, SubmissionCompleted, st.State)
+	assert.Equal(t, 1, st.Attempts)
+	assert.Empty(t, q.Pending())
+}
@@ -0,0 +1,104 @@
+package celestia
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/celestiaorg/celestia-node/share"
+
+	"github.com/rollkit/go-da"
+)
+
+const (
+	// subscribeRetryInterval is the delay after the first failed attempt to follow a height,
+	// doubled after each further attempt.
+	subscribeRetryInterval = time.Second
+	// subscribeMaxRetryInterval bounds the delay between attempts to follow a height.
+	subscribeMaxRetryInterval = 30 * time.Second
+)
+
+// HeightBlobs holds the IDs, and optionally the blobs, in a namespace at a height.
+type HeightBlobs struct {
+	Height uint64    `json:"height"`
+	IDs    []da.ID   `json:"ids"`
+	Blobs  []da.Blob `json:"blobs,omitempty"`
+}
+
+// Subscribe follows new heights of the node, and sends the IDs, and the blobs if withBlobs is set, in
+// the namespace for every height starting at fromHeight, including heights without blobs. A
+// fromHeight of 0 starts after the current local head of the node.
+//
+// Heights are sent in order without gaps, retrying failed requests until ctx is done, after which
+// the channel is closed. To resume after a reconnect, subscribe again from the last received height + 1.
+func (c *CelestiaDA) Subscribe(ctx context.Context, fromHeight uint64, ns da.Namespace, withBlobs bool) (<-chan *HeightBlobs, error) {
+	namespace, err := c.defaultNamespace(ns)
+	if err != nil {
+		return nil, err
+	}
+	if fromHeight == 0 {
+		head, err := c.client.Header.LocalHead(ctx)
+		if err != nil {
+			return nil, err
+		}
+		fromHeight = head.Height() + 1
+	}
+
+	ch := make(chan *HeightBlobs)
+	go func() {
+		defer close(ch)
+		delay := subscribeRetryInterval
+		for height := fromHeight; ; {
+			result, err := c.followHeight(ctx, height, namespace, withBlobs)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Println("failed to follow height", "height", height, "error", err, "retry", delay)
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+					return
+				}
+				delay = min(2*delay, subscribeMaxRetryInterval)
+				continue
+			}
+			delay = subscribeRetryInterval
+			select {
+			case ch <- result:
+			case <-ctx.Done():
+				return
+			}
+			height++
+		}
+	}()
+	return ch, nil
+}
+
+// followHeight waits until the node has the given height, and returns the blobs in the namespace.
+func (c *CelestiaDA) followHeight(ctx context.Context, height uint64, ns share.Namespace, withBlobs bool) (*HeightBlobs, error) {
+	if _, err := c.client.Header.WaitForHeight(ctx, height); err != nil {
+		return nil, err
+	}
+	return c.heightBlobs(ctx, height, ns, withBlobs)
+}
+
+// heightBlobs returns the IDs, and the blobs if withBlobs is set, in the namespace at the given height.
+func (c *CelestiaDA) heightBlobs(ctx context.Context, height uint64, ns share.Namespace, withBlobs bool) (*HeightBlobs, error) {
+	blobs, err := c.getAll(ctx, height, ns)
+	if err != nil {
+		return nil, err
+	}
+	result := &HeightBlobs{Height: height, IDs: make([]da.ID, 0, len(blobs))}
+	for _, b := range blobs {
+		result.IDs = append(result.IDs, makeID(height, b.commitment))
+		if withBlobs {
+			data, err := c.decodeBlob(ctx, ns, b.data)
+			if err != nil {
+				return nil, err
+			}
+			result.Blobs = append(result.Blobs, data)
+		}
+	}
+	return result, nil
+}
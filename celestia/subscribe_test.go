@@ -0,0 +1,48 @@
+package celestia
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCelestiaDA_Subscribe(t *testing.T) {
+	m := setup(t)
+	defer teardown(m)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	first, err := m.Submit(ctx, []Blob{[]byte("first")}, -1, nil)
+	require.NoError(t, err)
+
+	// subscribing from 0 starts after the local head
+	ch, err := m.Subscribe(ctx, 0, nil, true)
+	require.NoError(t, err)
+	second, err := m.Submit(ctx, []Blob{[]byte("second"), []byte("third")}, -1, nil)
+	require.NoError(t, err)
+	result := <-ch
+	assert.Equal(t, uint64(2), result.Height)
+	assert.Equal(t, second, result.IDs)
+	assert.Equal(t, []Blob{[]byte("second"), []byte("third")}, result.Blobs)
+
+	// resume from a given height, without blobs
+	resumed, err := m.Subscribe(ctx, 1, nil, false)
+	require.NoError(t, err)
+	result = <-resumed
+	assert.Equal(t, uint64(1), result.Height)
+	assert.Equal(t, first, result.IDs)
+	assert.Empty(t, result.Blobs)
+	result = <-resumed
+	assert.Equal(t, uint64(2), result.Height)
+
+	// the channel is closed when the context is done
+	subCtx, subCancel := context.WithCancel(ctx)
+	closed, err := m.Subscribe(subCtx, 10, nil, false)
+	require.NoError(t, err)
+	subCancel()
+	_, ok := <-closed
+	assert.False(t, ok)
+}
@@ -0,0 +1,561 @@
+package celestia
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	rpc "github.com/celestiaorg/celestia-node/api/rpc/client"
+	"github.com/celestiaorg/celestia-node/blob"
+	"github.com/celestiaorg/celestia-node/header"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/celestiaorg/celestia-node/state"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/rollkit/celestia-da/internal/telemetry"
+)
+
+// ErrNoUpstream is returned by Upstream if none of its endpoints is connected.
+var ErrNoUpstream = errors.New("no celestia-node endpoint is connected")
+
+// Strategy selects the endpoint of read requests of an Upstream.
+type Strategy string
+
+const (
+	// StrategyRoundRobin spreads reads over the healthy endpoints in turn.
+	StrategyRoundRobin Strategy = "round-robin"
+	// StrategyLatency sends reads to the healthy endpoint with the lowest health check latency.
+	StrategyLatency Strategy = "latency"
+)
+
+// ParseStrategy returns the strategy with the given name.
+func ParseStrategy(s string) (Strategy, error) {
+	for _, strategy := range []Strategy{StrategyRoundRobin, StrategyLatency} {
+		if string(strategy) == s {
+			return strategy, nil
+		}
+	}
+	return "", fmt.Errorf("unknown upstream strategy %q, must be %q or %q", s, StrategyRoundRobin, StrategyLatency)
+}
+
+// Endpoint is a celestia-node RPC endpoint.
+type Endpoint struct {
+	Address string
+	Token   string
+}
+
+// UpstreamConfig configures the celestia-node RPC endpoints of an Upstream.
+type UpstreamConfig struct {
+	// Endpoints are the celestia-node RPC endpoints. The first healthy endpoint is the primary,
+	// which all writes are sent to.
+	Endpoints []Endpoint
+	// Strategy selects the endpoint of reads.
+	Strategy Strategy
+	// HealthInterval is the delay between health checks of the endpoints.
+	HealthInterval time.Duration
+	// HealthTimeout bounds a health check.
+	HealthTimeout time.Duration
+	// MaxLag is the number of heights the local head of an endpoint may be behind the highest local
+	// head of all endpoints before it's considered unhealthy, 0 disables the check.
+	MaxLag uint64
+	// ReadAttempts is the number of attempts of a read before it fails, if no endpoint is reachable.
+	ReadAttempts int
+	// RetryInterval is the delay after the first failed read or reconnection attempt, doubled after
+	// each further attempt. Delays are jittered.
+	RetryInterval time.Duration
+	// MaxRetryInterval bounds the delay between attempts.
+	MaxRetryInterval time.Duration
+}
+
+// DefaultUpstreamConfig returns the default configuration for the given endpoints.
+func DefaultUpstreamConfig(endpoints ...Endpoint) UpstreamConfig {
+	return UpstreamConfig{
+		Endpoints:      endpoints,
+		Strategy:       StrategyRoundRobin,
+		HealthInterval: 10 * time.Second,
+		HealthTimeout:  5 * time.Second,
+
+		ReadAttempts:     5,
+		RetryInterval:    100 * time.Millisecond,
+		MaxRetryInterval: 5 * time.Second,
+	}
+}
+
+// EndpointStatus is the health of an endpoint as of its last health check.
+type EndpointStatus struct {
+	Address string `json:"address"`
+	Primary bool   `json:"primary"`
+	Healthy bool   `json:"healthy"`
+	// Head is the local head of the endpoint.
+	Head    uint64        `json:"head"`
+	Latency time.Duration `json:"latency"`
+	// Error is the reason the endpoint is unhealthy.
+	Error   string    `json:"error,omitempty"`
+	Checked time.Time `json:"checked"`
+}
+
+// endpoint is the connection to an endpoint and its health.
+type endpoint struct {
+	config Endpoint
+	// client is nil until the endpoint was connected.
+	client  *rpc.Client
+	healthy bool
+	head    uint64
+	latency time.Duration
+	err     error
+	checked time.Time
+	// reconnecting is true while the endpoint is reconnected after a connection error.
+	reconnecting bool
+}
+
+// Upstream connects to several celestia-node RPC endpoints, and provides a client that sends reads
+// to the healthy endpoints according to the strategy, failing over to the next endpoint if one is
+// unreachable, and writes to the primary endpoint.
+//
+// Reads are idempotent, and retried with backoff while no endpoint is reachable. Writes are never
+// retried, on another endpoint or the same one, as the unreachable endpoint may have broadcast the
+// transaction already. Endpoints are health checked in the background, and reconnected with
+// backoff after connection errors.
+type Upstream struct {
+	ctx    context.Context
+	config UpstreamConfig
+	client *rpc.Client
+
+	mu        sync.Mutex
+	endpoints []*endpoint
+	next      int
+
+	registration metric.Registration
+	cancel       context.CancelFunc
+	done         chan struct{}
+	reconnects   sync.WaitGroup
+}
+
+// NewUpstream connects to the endpoints of config, and starts health checking them. Endpoints that
+// can't be connected are retried by the health checks, NewUpstream fails only if none can be
+// connected. The upstream must be closed with Close.
+func NewUpstream(ctx context.Context, config UpstreamConfig) (*Upstream, error) {
+	if len(config.Endpoints) == 0 {
+		return nil, errors.New("at least one celestia-node endpoint is required")
+	}
+	if _, err := ParseStrategy(string(config.Strategy)); err != nil {
+		return nil, err
+	}
+	if config.HealthInterval <= 0 || config.HealthTimeout <= 0 {
+		return nil, fmt.Errorf("invalid upstream health interval %s and timeout %s", config.HealthInterval, config.HealthTimeout)
+	}
+	if config.ReadAttempts < 1 || config.RetryInterval <= 0 || config.MaxRetryInterval < config.RetryInterval {
+		return nil, fmt.Errorf("invalid upstream read attempts %d and retry intervals %s to %s",
+			config.ReadAttempts, config.RetryInterval, config.MaxRetryInterval)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	u := &Upstream{ctx: ctx, config: config, cancel: cancel, done: make(chan struct{})}
+	connected := false
+	for _, c := range config.Endpoints {
+		e := &endpoint{config: c}
+		if err := u.connect(e); err != nil {
+			log.Println("failed to connect to celestia-node endpoint", "address", c.Address, "error", err)
+		} else {
+			connected = true
+		}
+		u.endpoints = append(u.endpoints, e)
+	}
+	if !connected {
+		close(u.done)
+		u.Close()
+		return nil, ErrNoUpstream
+	}
+	reg, err := telemetry.Meter.RegisterCallback(u.observe, daMetrics.upstreamHealthy)
+	if err != nil {
+		close(u.done)
+		u.Close()
+		return nil, fmt.Errorf("failed to register upstream metric: %w", err)
+	}
+	u.registration = reg
+	u.client = u.newClient()
+	u.check()
+	go u.run()
+	return u, nil
+}
+
+// Client returns the client sending requests to the endpoints. It implements the methods of the
+// blob, header and state modules used by CelestiaDA.
+func (u *Upstream) Client() *rpc.Client {
+	return u.client
+}
+
+// Status returns the status of the endpoints, in order of configuration.
+func (u *Upstream) Status() []EndpointStatus {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	primary := u.primary()
+	statuses := make([]EndpointStatus, len(u.endpoints))
+	for i, e := range u.endpoints {
+		statuses[i] = EndpointStatus{
+			Address: e.config.Address,
+			Primary: e == primary,
+			Healthy: e.healthy,
+			Head:    e.head,
+			Latency: e.latency,
+			Checked: e.checked,
+		}
+		if e.err != nil {
+			statuses[i].Error = e.err.Error()
+		}
+	}
+	return statuses
+}
+
+// Close stops the health checks and closes the connections to the endpoints.
+func (u *Upstream) Close() {
+	// cancel with u.mu held, so no reconnection starts once Close waits for them
+	u.mu.Lock()
+	u.cancel()
+	u.mu.Unlock()
+	<-u.done
+	u.reconnects.Wait()
+	if u.registration != nil {
+		if err := u.registration.Unregister(); err != nil {
+			log.Println("failed to unregister upstream metric", "error", err)
+		}
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for _, e := range u.endpoints {
+		if e.client != nil {
+			e.client.Close()
+			e.client = nil
+		}
+	}
+}
+
+// connect replaces the connection to an endpoint with a new one.
+func (u *Upstream) connect(e *endpoint) error {
+	c, err := rpc.NewClient(u.ctx, e.config.Address, e.config.Token)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err != nil {
+		e.healthy, e.err = false, err
+		return err
+	}
+	if e.client != nil {
+		e.client.Close()
+	}
+	e.client = c
+	return nil
+}
+
+// run health checks the endpoints until the upstream is closed.
+func (u *Upstream) run() {
+	defer close(u.done)
+	for {
+		select {
+		case <-time.After(u.config.HealthInterval):
+			u.check()
+		case <-u.ctx.Done():
+			return
+		}
+	}
+}
+
+// check requests the local head of every endpoint, reconnecting endpoints that are unreachable.
+func (u *Upstream) check() {
+	var wg sync.WaitGroup
+	for _, e := range u.endpoints {
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+			u.checkEndpoint(e)
+		}(e)
+	}
+	wg.Wait()
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	var highest uint64
+	for _, e := range u.endpoints {
+		if e.healthy {
+			highest = max(highest, e.head)
+		}
+	}
+	for _, e := range u.endpoints {
+		if u.config.MaxLag > 0 && e.healthy && e.head+u.config.MaxLag < highest {
+			e.healthy = false
+			e.err = fmt.Errorf("local head %d is more than %d heights behind %d", e.head, u.config.MaxLag, highest)
+			log.Println("celestia-node endpoint is lagging", "address", e.config.Address, "head", e.head, "highest", highest)
+		}
+	}
+}
+
+// checkEndpoint requests the local head of an endpoint, and returns true if it's reachable.
+func (u *Upstream) checkEndpoint(e *endpoint) bool {
+	u.mu.Lock()
+	c, wasHealthy := e.client, e.healthy
+	u.mu.Unlock()
+	if c == nil {
+		if err := u.connect(e); err != nil {
+			return false
+		}
+		u.mu.Lock()
+		c = e.client
+		u.mu.Unlock()
+	}
+
+	ctx, cancel := context.WithTimeout(u.ctx, u.config.HealthTimeout)
+	defer cancel()
+	start := time.Now()
+	head, err := c.Header.LocalHead(ctx)
+	latency := time.Since(start)
+	if u.ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		if wasHealthy {
+			log.Println("celestia-node endpoint is unhealthy", "address", e.config.Address, "error", err)
+		}
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		e.healthy, e.err, e.checked = false, err, time.Now().UTC()
+		if isConnectionError(err) {
+			u.reconnect(e)
+		}
+		return false
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if !wasHealthy {
+		log.Println("celestia-node endpoint is healthy", "address", e.config.Address, "head", head.Height())
+	}
+	e.healthy, e.err, e.checked = true, nil, time.Now().UTC()
+	e.head = uint64(head.Height())
+	if e.latency == 0 {
+		e.latency = latency
+	} else {
+		// exponentially weighted moving average, so one slow check doesn't move reads elsewhere
+		e.latency = (4*e.latency + latency) / 5
+	}
+	return true
+}
+
+// reconnect starts reconnecting to an endpoint after a connection error, unless it's reconnected
+// already. Must be called with u.mu held.
+func (u *Upstream) reconnect(e *endpoint) {
+	if e.reconnecting || u.ctx.Err() != nil {
+		return
+	}
+	e.reconnecting = true
+	u.reconnects.Add(1)
+	go func() {
+		defer u.reconnects.Done()
+		for attempt := 1; ; attempt++ {
+			select {
+			case <-time.After(u.backoff(attempt)):
+			case <-u.ctx.Done():
+				return
+			}
+			if err := u.connect(e); err == nil && u.checkEndpoint(e) {
+				break
+			}
+		}
+		u.mu.Lock()
+		e.reconnecting = false
+		u.mu.Unlock()
+	}()
+}
+
+// backoff returns the delay before the given retry attempt, jittered so that concurrent retries
+// don't hit the endpoints at once.
+func (u *Upstream) backoff(attempt int) time.Duration {
+	delay := u.config.RetryInterval
+	for i := 1; i < attempt && delay < u.config.MaxRetryInterval; i++ {
+		delay *= 2
+	}
+	delay = min(delay, u.config.MaxRetryInterval)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func (u *Upstream) observe(_ context.Context, o metric.Observer) error {
+	for _, status := range u.Status() {
+		healthy := int64(0)
+		if status.Healthy {
+			healthy = 1
+		}
+		o.ObserveInt64(daMetrics.upstreamHealthy, healthy, metric.WithAttributes(
+			attribute.String("address", status.Address), attribute.Bool("primary", status.Primary)))
+	}
+	return nil
+}
+
+// primary returns the first healthy endpoint, or the first connected endpoint if none is healthy.
+// Must be called with u.mu held.
+func (u *Upstream) primary() *endpoint {
+	var connected *endpoint
+	for _, e := range u.endpoints {
+		if e.healthy && e.client != nil {
+			return e
+		}
+		if connected == nil && e.client != nil {
+			connected = e
+		}
+	}
+	return connected
+}
+
+// target is an endpoint and its connection at the time it was selected.
+type target struct {
+	endpoint *endpoint
+	client   *rpc.Client
+}
+
+// readTargets returns the healthy endpoints in the order of the strategy, followed by the
+// unhealthy connected endpoints as a last resort.
+func (u *Upstream) readTargets() []target {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	var healthy, unhealthy []target
+	for _, e := range u.endpoints {
+		switch {
+		case e.client == nil:
+		case e.healthy:
+			healthy = append(healthy, target{e, e.client})
+		default:
+			unhealthy = append(unhealthy, target{e, e.client})
+		}
+	}
+	switch u.config.Strategy {
+	case StrategyLatency:
+		slices.SortStableFunc(healthy, func(a, b target) int {
+			return cmp.Compare(a.endpoint.latency, b.endpoint.latency)
+		})
+	default:
+		if len(healthy) > 0 {
+			start := u.next % len(healthy)
+			healthy = append(healthy[start:], healthy[:start]...)
+			u.next++
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// writeTarget returns the primary endpoint.
+func (u *Upstream) writeTarget() (target, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	e := u.primary()
+	if e == nil {
+		return target{}, false
+	}
+	return target{e, e.client}, true
+}
+
+// failed marks an endpoint unhealthy after a connection error, and reconnects it.
+func (u *Upstream) failed(t target, err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if t.endpoint.healthy {
+		log.Println("celestia-node endpoint is unreachable", "address", t.endpoint.config.Address, "error", err)
+	}
+	t.endpoint.healthy, t.endpoint.err = false, err
+	u.reconnect(t.endpoint)
+}
+
+// upstreamRead calls fn with the connections of the read targets in order, until an endpoint was
+// reachable. If none was, it's retried after a backoff, up to the read attempts of u.
+func upstreamRead[T any](ctx context.Context, u *Upstream, fn func(*rpc.Client) (T, error)) (T, error) {
+	var zero T
+	for attempt := 1; ; attempt++ {
+		err := ErrNoUpstream
+		for _, t := range u.readTargets() {
+			var v T
+			if v, err = fn(t.client); err == nil || !isConnectionError(err) {
+				return v, err
+			}
+			u.failed(t, err)
+		}
+		if attempt >= u.config.ReadAttempts {
+			return zero, err
+		}
+		select {
+		case <-time.After(u.backoff(attempt)):
+		case <-ctx.Done():
+			return zero, err
+		}
+	}
+}
+
+// upstreamWrite calls fn with the connection of the primary endpoint, once.
+func upstreamWrite[T any](u *Upstream, fn func(*rpc.Client) (T, error)) (T, error) {
+	var zero T
+	t, ok := u.writeTarget()
+	if !ok {
+		return zero, ErrNoUpstream
+	}
+	v, err := fn(t.client)
+	if err != nil && isConnectionError(err) {
+		u.failed(t, err)
+	}
+	return v, err
+}
+
+// newClient returns a client whose methods are sent to the endpoints of u.
+func (u *Upstream) newClient() *rpc.Client {
+	c := &rpc.Client{}
+
+	c.Blob.Internal.Submit = func(ctx context.Context, blobs []*blob.Blob, gasPrice blob.GasPrice) (uint64, error) {
+		return upstreamWrite(u, func(c *rpc.Client) (uint64, error) { return c.Blob.Submit(ctx, blobs, gasPrice) })
+	}
+	c.Blob.Internal.Get = func(ctx context.Context, height uint64, ns share.Namespace, commitment blob.Commitment) (*blob.Blob, error) {
+		return upstreamRead(ctx, u, func(c *rpc.Client) (*blob.Blob, error) { return c.Blob.Get(ctx, height, ns, commitment) })
+	}
+	c.Blob.Internal.GetAll = func(ctx context.Context, height uint64, namespaces []share.Namespace) ([]*blob.Blob, error) {
+		return upstreamRead(ctx, u, func(c *rpc.Client) ([]*blob.Blob, error) { return c.Blob.GetAll(ctx, height, namespaces) })
+	}
+	c.Blob.Internal.GetProof = func(ctx context.Context, height uint64, ns share.Namespace, commitment blob.Commitment) (*blob.Proof, error) {
+		return upstreamRead(ctx, u, func(c *rpc.Client) (*blob.Proof, error) { return c.Blob.GetProof(ctx, height, ns, commitment) })
+	}
+	c.Blob.Internal.Included = func(ctx context.Context, height uint64, ns share.Namespace, proof *blob.Proof, commitment blob.Commitment) (bool, error) {
+		return upstreamRead(ctx, u, func(c *rpc.Client) (bool, error) { return c.Blob.Included(ctx, height, ns, proof, commitment) })
+	}
+
+	c.Header.Internal.LocalHead = func(ctx context.Context) (*header.ExtendedHeader, error) {
+		return upstreamRead(ctx, u, func(c *rpc.Client) (*header.ExtendedHeader, error) { return c.Header.LocalHead(ctx) })
+	}
+	c.Header.Internal.GetByHeight = func(ctx context.Context, height uint64) (*header.ExtendedHeader, error) {
+		return upstreamRead(ctx, u, func(c *rpc.Client) (*header.ExtendedHeader, error) { return c.Header.GetByHeight(ctx, height) })
+	}
+	c.Header.Internal.WaitForHeight = func(ctx context.Context, height uint64) (*header.ExtendedHeader, error) {
+		return upstreamRead(ctx, u, func(c *rpc.Client) (*header.ExtendedHeader, error) { return c.Header.WaitForHeight(ctx, height) })
+	}
+	c.Header.Internal.NetworkHead = func(ctx context.Context) (*header.ExtendedHeader, error) {
+		return upstreamRead(ctx, u, func(c *rpc.Client) (*header.ExtendedHeader, error) { return c.Header.NetworkHead(ctx) })
+	}
+	c.Header.Internal.SyncWait = func(ctx context.Context) error {
+		_, err := upstreamRead(ctx, u, func(c *rpc.Client) (struct{}, error) { return struct{}{}, c.Header.SyncWait(ctx) })
+		return err
+	}
+	c.Header.Internal.Subscribe = func(ctx context.Context) (<-chan *header.ExtendedHeader, error) {
+		return upstreamRead(ctx, u, func(c *rpc.Client) (<-chan *header.ExtendedHeader, error) { return c.Header.Subscribe(ctx) })
+	}
+
+	// the account is the account of the primary, which pays for submissions
+	c.State.Internal.AccountAddress = func(ctx context.Context) (state.Address, error) {
+		return upstreamWrite(u, func(c *rpc.Client) (state.Address, error) { return c.State.AccountAddress(ctx) })
+	}
+	c.State.Internal.Balance = func(ctx context.Context) (*state.Balance, error) {
+		return upstreamWrite(u, func(c *rpc.Client) (*state.Balance, error) { return c.State.Balance(ctx) })
+	}
+	c.State.Internal.SubmitPayForBlob = func(ctx context.Context, fee state.Int, gasLim uint64, blobs []*blob.Blob) (*state.TxResponse, error) {
+		return upstreamWrite(u, func(c *rpc.Client) (*state.TxResponse, error) {
+			return c.State.SubmitPayForBlob(ctx, fee, gasLim, blobs)
+		})
+	}
+	return c
+}
@@ -0,0 +1,163 @@
+package celestia
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpstream(t *testing.T) {
+	ctx := context.TODO()
+	primary, secondary := NewMockService(), NewMockService()
+	defer secondary.Close()
+
+	config := DefaultUpstreamConfig(Endpoint{Address: primary.URL()}, Endpoint{Address: secondary.URL()})
+	config.HealthInterval = 10 * time.Millisecond
+	config.RetryInterval = time.Millisecond
+	config.MaxRetryInterval = 10 * time.Millisecond
+	upstream, err := NewUpstream(ctx, config)
+	require.NoError(t, err)
+	defer upstream.Close()
+	ns, err := share.NewBlobNamespaceV0([]byte("upstream"))
+	require.NoError(t, err)
+	c := NewCelestiaDA(upstream.Client(), ns, -1, ctx)
+
+	// writes are sent to the primary
+	_, err = c.Submit(ctx, []Blob{[]byte("blob")}, -1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), primary.blob.currentHeight())
+	assert.Equal(t, uint64(0), secondary.blob.currentHeight())
+
+	// reads are spread over the endpoints
+	heads := make(map[uint64]int)
+	for i := 0; i < 4; i++ {
+		head, err := upstream.Client().Header.LocalHead(ctx)
+		require.NoError(t, err)
+		heads[head.Height()]++
+	}
+	assert.Equal(t, map[uint64]int{0: 2, 1: 2}, heads)
+
+	status := upstream.Status()
+	require.Len(t, status, 2)
+	assert.True(t, status[0].Primary)
+	assert.True(t, status[0].Healthy)
+	assert.True(t, status[1].Healthy)
+
+	// reads fail over to the secondary right away, writes once the primary is known to be down
+	primary.Close()
+	for i := 0; i < 4; i++ {
+		head, err := upstream.Client().Header.LocalHead(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), head.Height())
+	}
+	assert.Eventually(t, func() bool {
+		status := upstream.Status()
+		return !status[0].Healthy && status[1].Primary
+	}, time.Second, 10*time.Millisecond)
+	_, err = c.Submit(ctx, []Blob{[]byte("blob")}, -1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), secondary.blob.currentHeight())
+
+	secondary.Close()
+	_, err = upstream.Client().Header.LocalHead(ctx)
+	assert.Error(t, err)
+}
+
+func TestUpstream_Latency(t *testing.T) {
+	slow, fast := NewMockService(), NewMockService()
+	defer slow.Close()
+	defer fast.Close()
+
+	config := DefaultUpstreamConfig(Endpoint{Address: slow.URL()}, Endpoint{Address: fast.URL()})
+	config.Strategy = StrategyLatency
+	upstream, err := NewUpstream(context.TODO(), config)
+	require.NoError(t, err)
+	defer upstream.Close()
+
+	upstream.mu.Lock()
+	upstream.endpoints[0].latency = 10 * time.Millisecond
+	upstream.endpoints[1].latency = time.Millisecond
+	upstream.mu.Unlock()
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, fast.URL(), upstream.readTargets()[0].endpoint.config.Address)
+	}
+
+	config.Strategy = "random"
+	_, err = NewUpstream(context.TODO(), config)
+	assert.Error(t, err)
+	config.Strategy, config.ReadAttempts = StrategyLatency, 0
+	_, err = NewUpstream(context.TODO(), config)
+	assert.Error(t, err)
+	_, err = NewUpstream(context.TODO(), DefaultUpstreamConfig())
+	assert.Error(t, err)
+}
+
+func TestUpstream_Retry(t *testing.T) {
+	ctx := context.TODO()
+	mock := NewMockService()
+	defer mock.Close()
+
+	// the flaky server drops the connection of the next failures requests other than health checks,
+	// and counts submissions
+	var failures, submissions atomic.Int64
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if bytes.Contains(body, []byte("Submit")) {
+			submissions.Add(1)
+		}
+		if !bytes.Contains(body, []byte("LocalHead")) && failures.Add(-1) >= 0 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			_ = conn.Close()
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		mock.server.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer flaky.Close()
+
+	config := DefaultUpstreamConfig(Endpoint{Address: flaky.URL})
+	config.ReadAttempts = 3
+	config.RetryInterval = time.Millisecond
+	config.MaxRetryInterval = 10 * time.Millisecond
+	upstream, err := NewUpstream(ctx, config)
+	require.NoError(t, err)
+	defer upstream.Close()
+	ns, err := share.NewBlobNamespaceV0([]byte("retry"))
+	require.NoError(t, err)
+	c := NewCelestiaDA(upstream.Client(), ns, -1, ctx)
+	healthy := func() bool { return upstream.Status()[0].Healthy }
+
+	// reads are retried until the endpoint is reachable again
+	ids, err := c.Submit(ctx, []Blob{[]byte("blob")}, -1, nil)
+	require.NoError(t, err)
+	failures.Store(2)
+	blobs, err := c.Get(ctx, ids, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []Blob{[]byte("blob")}, blobs)
+
+	// up to the read attempts
+	assert.Eventually(t, healthy, time.Second, time.Millisecond)
+	failures.Store(3)
+	_, err = c.Get(ctx, ids, nil)
+	assert.True(t, isConnectionError(err))
+
+	// the endpoint is reconnected in the background, long before its next health check
+	assert.Eventually(t, healthy, time.Second, time.Millisecond)
+
+	// submissions are never retried
+	failures.Store(1)
+	submissions.Store(0)
+	_, err = c.Submit(ctx, []Blob{[]byte("blob")}, -1, nil)
+	assert.True(t, isConnectionError(err))
+	assert.Equal(t, int64(1), submissions.Load())
+	assert.Equal(t, uint64(1), mock.blob.currentHeight())
+}
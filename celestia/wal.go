@@ -0,0 +1,195 @@
+package celestia
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// walFile is the name of the write-ahead log in the queue directory.
+const walFile = "queue.wal"
+
+// maxWALRecordSize bounds the size of a single record written to the log.
+const maxWALRecordSize = 2 * maxDecodedBlobSize
+
+var walTable = crc32.MakeTable(crc32.Castagnoli)
+
+// wal is an append-only log of queue records. Each record is stored as
+//
+//	length (4 bytes, big endian) | crc32c of the payload (4 bytes, big endian) | JSON payload
+//
+// and synced to disk before append returns. A torn record at the end of the log, left by a crash
+// during append, is discarded when the log is opened, see readWAL.
+type wal struct {
+	dir string
+	f   *os.File
+	// records is the number of records in the log.
+	records int
+}
+
+// openWAL opens the log in dir, creating it if needed, and returns the records it contains.
+func openWAL(dir string) (*wal, []walRecord, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, walFile), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, nil, err
+	}
+	records, offset, err := readWAL(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	// drop a torn record, if any, and continue appending after the last complete record
+	if err := f.Truncate(offset); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return &wal{dir: dir, f: f, records: len(records)}, records, nil
+}
+
+// readWAL reads the records of the log, and returns the offset after the last complete record. A
+// record extending beyond the end of the log, or a corrupt last record, is a torn record left by a
+// crash during append. Corrupt records followed by others fail the read instead, as the log can't
+// be truncated there without losing acknowledged submissions.
+func readWAL(f *os.File) ([]walRecord, int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, err
+	}
+	r := bufio.NewReader(f)
+	var records []walRecord
+	var offset int64
+	for {
+		var header [8]byte
+		if offset+int64(len(header)) > info.Size() {
+			return records, offset, nil
+		}
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, 0, err
+		}
+		size := binary.BigEndian.Uint32(header[:4])
+		end := offset + int64(len(header)) + int64(size)
+		if end > info.Size() {
+			return records, offset, nil
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return nil, 0, err
+		}
+		if crc32.Checksum(payload, walTable) != binary.BigEndian.Uint32(header[4:]) {
+			if end == info.Size() {
+				return records, offset, nil
+			}
+			return nil, 0, fmt.Errorf("corrupt queue log record at offset %d", offset)
+		}
+		var record walRecord
+		if err := json.Unmarshal(payload, &record); err != nil {
+			return nil, 0, fmt.Errorf("invalid queue log record at offset %d: %w", offset, err)
+		}
+		records = append(records, record)
+		offset = end
+	}
+}
+
+// append writes records to the log and syncs it.
+func (w *wal) append(records ...walRecord) error {
+	buf, err := encodeWALRecords(records)
+	if err != nil {
+		return err
+	}
+	return w.write(buf, len(records))
+}
+
+// write writes n records encoded by encodeWALRecords to the log and syncs it.
+func (w *wal) write(buf []byte, n int) error {
+	if _, err := w.f.Write(buf); err != nil {
+		return err
+	}
+	if err := w.f.Sync(); err != nil {
+		return err
+	}
+	w.records += n
+	return nil
+}
+
+// rewrite atomically replaces the log with the given records.
+func (w *wal) rewrite(records []walRecord) error {
+	buf, err := encodeWALRecords(records)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(w.dir, walFile)
+	tmp, err := os.OpenFile(path+".tmp", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := syncDir(w.dir); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekEnd); err != nil {
+		tmp.Close()
+		return err
+	}
+	old := w.f
+	w.f, w.records = tmp, len(records)
+	return old.Close()
+}
+
+func (w *wal) close() error {
+	return w.f.Close()
+}
+
+// encodeWALRecords encodes records for the log, refusing records larger than maxWALRecordSize.
+func encodeWALRecords(records []walRecord) ([]byte, error) {
+	var buf []byte
+	for _, record := range records {
+		payload, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		if len(payload) > maxWALRecordSize {
+			return nil, fmt.Errorf("%w: queued submission of %d bytes exceeds %d bytes", ErrBlobTooLarge, len(payload), maxWALRecordSize)
+		}
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(payload)))
+		buf = binary.BigEndian.AppendUint32(buf, crc32.Checksum(payload, walTable))
+		buf = append(buf, payload...)
+	}
+	return buf, nil
+}
+
+// syncDir makes a rename in dir durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -2,9 +2,14 @@ package main
 
 import (
 	"crypto/rand"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/celestiaorg/celestia-node/api/rpc/perms"
 	"github.com/celestiaorg/celestia-node/libs/authtoken"
@@ -16,6 +21,36 @@ import (
 	"github.com/mitchellh/go-homedir"
 )
 
+// tokenEnvVar is the environment variable used by celestia-node CLI tools for the RPC auth token.
+const tokenEnvVar = "CELESTIA_NODE_AUTH_TOKEN" // #nosec G101
+
+// adminTokenEnvVar is the environment variable holding the admin token of the celestia-da service.
+const adminTokenEnvVar = "CELESTIA_DA_ADMIN_TOKEN" // #nosec G101
+
+// adminToken returns the admin token of the celestia-da service from a file or the environment,
+// empty if neither is set.
+func adminToken(file string) (string, error) {
+	if file == "" {
+		return strings.TrimSpace(os.Getenv(adminTokenEnvVar)), nil
+	}
+	path, err := homedir.Expand(filepath.Clean(file))
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read admin token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("admin token file %s is empty", path)
+	}
+	return token, nil
+}
+
+// errNoSecret is returned when the keystore has no JWT secret and creating one wasn't allowed.
+var errNoSecret = errors.New("no JWT secret found in keystore")
+
 func buildJWTToken(body []byte, permissions []auth.Permission) (string, error) {
 	signer, err := jwt.NewHS256(body)
 	if err != nil {
@@ -24,6 +59,24 @@ func buildJWTToken(body []byte, permissions []auth.Permission) (string, error) {
 	return authtoken.NewSignedJWT(signer, permissions)
 }
 
+// checkTokenExpiry returns an error if token carries an expiry claim in the past.
+//
+// The signature is not verified, that's up to celestia-node.
+func checkTokenExpiry(token string) error {
+	parsed, err := jwt.ParseString(token)
+	if err != nil {
+		return fmt.Errorf("invalid auth token: %w", err)
+	}
+	var claims jwt.StandardClaims
+	if err := json.Unmarshal(parsed.RawClaims(), &claims); err != nil {
+		return fmt.Errorf("invalid auth token claims: %w", err)
+	}
+	if claims.IsExpired(time.Now()) {
+		return fmt.Errorf("auth token expired at %s", claims.ExpiresAt.Time())
+	}
+	return nil
+}
+
 func generateNewKey(ks keystore.Keystore) (keystore.PrivKey, error) {
 	sk, err := io.ReadAll(io.LimitReader(rand.Reader, 32))
 	if err != nil {
@@ -46,7 +99,53 @@ func newKeystore(path string) (keystore.Keystore, error) {
 	return keystore.NewFSKeystore(filepath.Join(expanded, "keys"), nil)
 }
 
-func authToken(path string) (string, error) {
+// daPermissions returns the minimal set of permissions required by the DA service.
+func daPermissions(readOnly bool) []auth.Permission {
+	if readOnly {
+		return perms.ReadPerms
+	}
+	return perms.ReadWritePerms
+}
+
+// tokenOptions configures how the DA service obtains its celestia-node RPC auth token.
+type tokenOptions struct {
+	// file is a path to a file containing the token.
+	file string
+	// readOnly restricts a generated token to read permissions.
+	readOnly bool
+	// createSecret allows generating and persisting a new JWT secret if the keystore has none.
+	createSecret bool
+}
+
+// resolveToken returns the token from a file or the environment, falling back to a token
+// generated from the node store keystore, or no token if storePath is empty.
+func resolveToken(storePath string, opts tokenOptions) (string, error) {
+	var token string
+	switch {
+	case opts.file != "":
+		path, err := homedir.Expand(filepath.Clean(opts.file))
+		if err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read auth token file: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	case os.Getenv(tokenEnvVar) != "":
+		token = strings.TrimSpace(os.Getenv(tokenEnvVar))
+	case storePath == "":
+		return "", nil
+	default:
+		return authToken(storePath, opts)
+	}
+	if err := checkTokenExpiry(token); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func authToken(path string, opts tokenOptions) (string, error) {
 	ks, err := newKeystore(path)
 	if err != nil {
 		return "", err
@@ -57,13 +156,19 @@ func authToken(path string) (string, error) {
 		if !errors.Is(err, keystore.ErrNotFound) {
 			return "", err
 		}
+		if !opts.createSecret {
+			return "", fmt.Errorf("%w at %s: the node creates it on its first start, so start the node once "+
+				"before celestia-da, allow creating it with --%s, or pass a token with --%s or $%s",
+				errNoSecret, ks.Path(), grpcCreateSecretFlag, grpcTokenFileFlag, tokenEnvVar)
+		}
+		log.Warnw("creating new JWT secret", "keystore", ks.Path())
 		key, err = generateNewKey(ks)
 		if err != nil {
 			return "", err
 		}
 	}
 
-	token, err := buildJWTToken(key.Body, perms.ReadWritePerms)
+	token, err := buildJWTToken(key.Body, daPermissions(opts.readOnly))
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,927 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	rpc "github.com/celestiaorg/celestia-node/api/rpc/client"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/rollkit/celestia-da/celestia"
+	"github.com/rollkit/celestia-da/server"
+	"github.com/rollkit/go-da"
+	proxygrpc "github.com/rollkit/go-da/proxy/grpc"
+)
+
+const (
+	clientAddrFlag      = "address"
+	clientNodeAddrFlag  = "node.address"
+	clientNodeTokenFlag = "node.token" // #nosec G101
+	clientNamespaceFlag = "namespace"
+	clientGasPriceFlag  = "gasprice"
+	clientOutputFlag    = "output"
+	clientTimeoutFlag   = "timeout"
+	clientWatchFlag     = "watch"
+	clientFromFlag      = "from"
+	clientBlobsFlag     = "blobs"
+	clientLimitFlag     = "limit"
+	clientScanFlag      = "scan"
+	clientReceiptsFlag  = "receipts"
+	clientClientFlag    = "client"
+	clientKeyFlag       = "key"
+
+	clientAdminTokenFlag = "admin.token.file" // #nosec G101
+)
+
+const (
+	outputHex    = "hex"
+	outputBase64 = "base64"
+	outputJSON   = "json"
+)
+
+var clientCmd = &cobra.Command{
+	Use:   "client",
+	Short: "Send requests to a running celestia-da gRPC service or directly to a celestia-node",
+	Long: `Send requests to a running celestia-da gRPC service (--address) or, bypassing the
+gRPC service, directly to a celestia-node RPC endpoint (--node.address).
+
+IDs and proofs given as arguments may be hex or base64 encoded. An argument
+starting with @ is read from the named file instead.`,
+	Args: cobra.NoArgs,
+}
+
+func init() {
+	flags := clientCmd.PersistentFlags()
+	flags.String(clientAddrFlag, "", "celestia-da gRPC service address, e.g. 127.0.0.1:9292")
+	flags.String(clientNodeAddrFlag, "", "celestia-node RPC endpoint address, used instead of --address")
+	flags.String(clientNodeTokenFlag, "", "celestia-node RPC auth token, used with --node.address (default $"+tokenEnvVar+")")
+	flags.String(clientNamespaceFlag, "", "namespace (hex or base64 encoded), required with --node.address")
+	flags.Float64(clientGasPriceFlag, -1, "gas price for submit (utia/gas) default: -1 for default fees")
+	flags.String(clientOutputFlag, outputHex, "output format: \"hex\", \"base64\" or \"json\"")
+	flags.Duration(clientTimeoutFlag, time.Minute, "request timeout")
+	flags.String(clientAdminTokenFlag, "", "path to a file containing the admin token of the celestia-da service, used by admin commands (default $"+adminTokenEnvVar+")")
+
+	clientSubmitCmd := newClientCmd("submit <file>", "Submit the contents of a file as a blob", cobra.ExactArgs(1), runSubmit)
+	clientSubmitCmd.Flags().Bool(clientReceiptsFlag, false, "print the transaction hash, fee, gas and shares of the submission, implies --output json")
+	clientSubmitCmd.Flags().String(clientKeyFlag, "", "idempotency key: retrying with the same key returns the result of the first submission instead of submitting again, used with --address")
+	clientCmd.AddCommand(
+		clientSubmitCmd,
+		newClientCmd("get <id>...", "Get blobs by ID", cobra.MinimumNArgs(1), runGet),
+		newClientCmd("get-ids <height>", "Get IDs of all blobs in the namespace at a height", cobra.ExactArgs(1), runGetIDs),
+		newClientCmd("proofs <id>...", "Get inclusion proofs for blobs by ID", cobra.MinimumNArgs(1), runProofs),
+		newClientCmd("validate <id> <proof>", "Validate an inclusion proof for a blob ID", cobra.ExactArgs(2), runValidate),
+		newClientCmd("commit <file>", "Compute the commitment of the contents of a file", cobra.ExactArgs(1), runCommit),
+		newClientCmd("estimate-fee <file>...", "Estimate the gas and fee of submitting the contents of files as blobs", cobra.MinimumNArgs(1), runEstimateFee),
+		clientStatusCmd,
+		clientSubscribeCmd,
+		clientRangeCmd,
+		clientIndexCmd,
+		clientBalanceCmd,
+		clientUsageCmd,
+		clientAdminCmd,
+		clientGetByCommitmentCmd,
+	)
+	clientStatusCmd.Flags().Bool(clientWatchFlag, false, "print every status change until the submission is final")
+	clientSubscribeCmd.Flags().Uint64(clientFromFlag, 0, "first height to print, 0 starts after the current head")
+	clientSubscribeCmd.Flags().Bool(clientBlobsFlag, false, "print blobs in addition to their IDs")
+	clientRangeCmd.Flags().Bool(clientBlobsFlag, false, "print blobs in addition to their IDs")
+
+	clientUsageCmd.Flags().String(clientClientFlag, "", "identity of the client to report the usage of, the host of its address by default")
+
+	clientGetByCommitmentCmd.Flags().Uint64(clientScanFlag, 0, "heights below the head to scan for blobs missing from the submission index, 0 scans 100")
+
+	clientAdminCmd.AddCommand(
+		clientAdminConfigCmd,
+		clientAdminQueueCmd,
+		clientAdminGasPriceCmd,
+		clientAdminLimitsCmd,
+		clientAdminPauseCmd,
+		clientAdminResumeCmd,
+		clientAdminFlushCacheCmd,
+		clientAdminLogLevelCmd,
+	)
+
+	clientIndexCmd.AddCommand(clientIndexLookupCmd, clientIndexListCmd)
+	clientIndexListCmd.Flags().Uint64(clientFromFlag, 0, "first height to list")
+	clientIndexListCmd.Flags().Int(clientLimitFlag, 0, "maximum number of entries to list, 0 lists all")
+}
+
+var clientGetByCommitmentCmd = &cobra.Command{
+	Use:          "get-by-commitment <commitment>",
+	Short:        "Get the ID, blob and inclusion proof of a blob by commitment, without knowing its height",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString(clientAddrFlag)
+		output, _ := cmd.Flags().GetString(clientOutputFlag)
+		timeout, _ := cmd.Flags().GetDuration(clientTimeoutFlag)
+		nsString, _ := cmd.Flags().GetString(clientNamespaceFlag)
+		scan, _ := cmd.Flags().GetUint64(clientScanFlag)
+		if addr == "" {
+			return fmt.Errorf("--%s is required", clientAddrFlag)
+		}
+		if output != outputHex && output != outputBase64 && output != outputJSON {
+			return fmt.Errorf("unknown output format %q", output)
+		}
+		values, err := decodeArgs(args)
+		if err != nil {
+			return err
+		}
+		req := server.CommitmentRequest{Commitment: values[0], ScanDepth: scan}
+		if nsString != "" {
+			if req.Namespace, err = celestia.ParseNamespace(nsString); err != nil {
+				return err
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+		defer cancel()
+		conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		result, err := server.NewCommitmentClient(conn).Get(ctx, req)
+		if err != nil {
+			return err
+		}
+		return printResult(output, result)
+	},
+}
+
+var clientBalanceCmd = &cobra.Command{
+	Use:          "balance",
+	Short:        "Print the balance of the node account of a celestia-da service",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runAdminClient(cmd, func(ctx context.Context, client *server.AdminClient) (interface{}, error) {
+			return client.Balance(ctx)
+		})
+	},
+}
+
+var clientUsageCmd = &cobra.Command{
+	Use:          "usage",
+	Short:        "Print the usage today of a client and namespace of a celestia-da service running with --" + grpcLimitsFlag,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		nsString, _ := cmd.Flags().GetString(clientNamespaceFlag)
+		req := server.UsageRequest{}
+		req.Client, _ = cmd.Flags().GetString(clientClientFlag)
+		if nsString != "" {
+			var err error
+			if req.Namespace, err = celestia.ParseNamespace(nsString); err != nil {
+				return err
+			}
+		}
+		return runAdminClient(cmd, func(ctx context.Context, client *server.AdminClient) (interface{}, error) {
+			return client.Usage(ctx, req)
+		})
+	},
+}
+
+var clientAdminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: "Inspect and operate a running celestia-da service",
+	Long: `Inspect and operate a running celestia-da service. Commands changing the
+service require the admin token of the service, read from --` + clientAdminTokenFlag + ` or
+$` + adminTokenEnvVar + `.`,
+	Args: cobra.NoArgs,
+}
+
+var clientAdminConfigCmd = &cobra.Command{
+	Use:          "config",
+	Short:        "Print the configuration of the service",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runAdminClient(cmd, func(ctx context.Context, client *server.AdminClient) (interface{}, error) {
+			return client.Config(ctx)
+		})
+	},
+}
+
+var clientAdminQueueCmd = &cobra.Command{
+	Use:          "queue",
+	Short:        "Print the pending submissions of a service running with --" + grpcQueueFlag,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runAdminClient(cmd, func(ctx context.Context, client *server.AdminClient) (interface{}, error) {
+			return client.Queue(ctx)
+		})
+	},
+}
+
+var clientAdminGasPriceCmd = &cobra.Command{
+	Use:          "gas-price <price>",
+	Short:        "Change the gas price (utia/gas) of submissions without a gas price, -1 for the default minimum gas price",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		gasPrice, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			return fmt.Errorf("invalid gas price %q: %w", args[0], err)
+		}
+		return runAdminClient(cmd, func(ctx context.Context, client *server.AdminClient) (interface{}, error) {
+			return client.SetGasPrice(ctx, gasPrice)
+		})
+	},
+}
+
+var clientAdminLimitsCmd = &cobra.Command{
+	Use:          "limits <file>",
+	Short:        "Replace the rate limits and quotas of a service running with --" + grpcLimitsFlag + " with those of a JSON file",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limits, err := server.LoadLimitsConfig(args[0])
+		if err != nil {
+			return err
+		}
+		return runAdminClient(cmd, func(ctx context.Context, client *server.AdminClient) (interface{}, error) {
+			return client.SetLimits(ctx, limits)
+		})
+	},
+}
+
+var clientAdminPauseCmd = &cobra.Command{
+	Use:          "pause",
+	Short:        "Pause submissions, queued submissions are held until resumed",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runAdminClient(cmd, func(ctx context.Context, client *server.AdminClient) (interface{}, error) {
+			return client.Pause(ctx)
+		})
+	},
+}
+
+var clientAdminResumeCmd = &cobra.Command{
+	Use:          "resume",
+	Short:        "Resume paused submissions",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runAdminClient(cmd, func(ctx context.Context, client *server.AdminClient) (interface{}, error) {
+			return client.Resume(ctx)
+		})
+	},
+}
+
+var clientAdminLogLevelCmd = &cobra.Command{
+	Use:          "log-level <level> [subsystem]",
+	Short:        "Change the log level of a logging subsystem of the node, or of all subsystems",
+	Args:         cobra.RangeArgs(1, 2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		subsystem := ""
+		if len(args) > 1 {
+			subsystem = args[1]
+		}
+		return runAdminClient(cmd, func(ctx context.Context, client *server.AdminClient) (interface{}, error) {
+			return client.SetLogLevel(ctx, args[0], subsystem)
+		})
+	},
+}
+
+var clientAdminFlushCacheCmd = &cobra.Command{
+	Use:          "flush-cache",
+	Short:        "Drop the cache of retrieved blobs of a service running with --" + grpcCacheFlag,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runAdminClient(cmd, func(ctx context.Context, client *server.AdminClient) (interface{}, error) {
+			return client.FlushCache(ctx)
+		})
+	},
+}
+
+// runAdminClient prints the result of fn called with a client for the admin service at --address,
+// authenticated with the token read from --admin.token.file or the environment.
+func runAdminClient(cmd *cobra.Command, fn func(ctx context.Context, client *server.AdminClient) (interface{}, error)) error {
+	addr, _ := cmd.Flags().GetString(clientAddrFlag)
+	output, _ := cmd.Flags().GetString(clientOutputFlag)
+	timeout, _ := cmd.Flags().GetDuration(clientTimeoutFlag)
+	tokenFile, _ := cmd.Flags().GetString(clientAdminTokenFlag)
+	if addr == "" {
+		return fmt.Errorf("--%s is required", clientAddrFlag)
+	}
+	token, err := adminToken(tokenFile)
+	if err != nil {
+		return err
+	}
+	var opts []grpc.CallOption
+	if token != "" {
+		opts = append(opts, server.AdminToken(token))
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	result, err := fn(ctx, server.NewAdminClient(conn, opts...))
+	if err != nil {
+		return err
+	}
+	return printResult(output, result)
+}
+
+var clientIndexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Query the index of blobs submitted by a celestia-da service running with --" + grpcIndexFlag,
+	Args:  cobra.NoArgs,
+}
+
+var clientIndexLookupCmd = &cobra.Command{
+	Use:          "lookup <commitment|id>",
+	Short:        "Print the heights a blob was submitted at",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		values, err := decodeArgs(args)
+		if err != nil {
+			return err
+		}
+		commitment := values[0]
+		if len(commitment) == idSize {
+			// an ID is the little endian height followed by the commitment
+			commitment = commitment[8:]
+		}
+		return runIndexClient(cmd, func(ctx context.Context, client *server.IndexClient, output string) error {
+			entries, err := client.Lookup(ctx, commitment)
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				return errors.New("no submission with this commitment in the index")
+			}
+			for _, entry := range entries {
+				if err := printResult(output, entry); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	},
+}
+
+var clientIndexListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "Print the blobs submitted to the namespace",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		nsString, _ := cmd.Flags().GetString(clientNamespaceFlag)
+		from, _ := cmd.Flags().GetUint64(clientFromFlag)
+		limit, _ := cmd.Flags().GetInt(clientLimitFlag)
+		req := server.ListRequest{FromHeight: from, Limit: limit}
+		if nsString != "" {
+			ns, err := celestia.ParseNamespace(nsString)
+			if err != nil {
+				return err
+			}
+			req.Namespace = ns
+		}
+		return runIndexClient(cmd, func(ctx context.Context, client *server.IndexClient, output string) error {
+			printed := 0
+			for {
+				page, err := client.List(ctx, req)
+				if err != nil {
+					return err
+				}
+				for _, entry := range page.Entries {
+					if err := printResult(output, entry); err != nil {
+						return err
+					}
+				}
+				printed += len(page.Entries)
+				if page.Next == 0 || (limit > 0 && printed >= limit) {
+					return nil
+				}
+				req.FromHeight = page.Next
+				if limit > 0 {
+					req.Limit = limit - printed
+				}
+			}
+		})
+	},
+}
+
+// runIndexClient calls fn with a client for the submission index service at --address.
+func runIndexClient(cmd *cobra.Command, fn func(ctx context.Context, client *server.IndexClient, output string) error) error {
+	addr, _ := cmd.Flags().GetString(clientAddrFlag)
+	output, _ := cmd.Flags().GetString(clientOutputFlag)
+	timeout, _ := cmd.Flags().GetDuration(clientTimeoutFlag)
+	if addr == "" {
+		return fmt.Errorf("--%s is required", clientAddrFlag)
+	}
+	if output != outputHex && output != outputBase64 && output != outputJSON {
+		return fmt.Errorf("unknown output format %q", output)
+	}
+	ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return fn(ctx, server.NewIndexClient(conn), output)
+}
+
+// idSize is the size of a blob ID, the 8 byte height followed by the 32 byte commitment.
+const idSize = 8 + 32
+
+var clientRangeCmd = &cobra.Command{
+	Use:          "range <from> <to>",
+	Short:        "Print the IDs of blobs in the namespace at heights from to to, inclusive",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString(clientAddrFlag)
+		output, _ := cmd.Flags().GetString(clientOutputFlag)
+		timeout, _ := cmd.Flags().GetDuration(clientTimeoutFlag)
+		nsString, _ := cmd.Flags().GetString(clientNamespaceFlag)
+		blobs, _ := cmd.Flags().GetBool(clientBlobsFlag)
+		if addr == "" {
+			return fmt.Errorf("--%s is required", clientAddrFlag)
+		}
+		if output != outputHex && output != outputBase64 && output != outputJSON {
+			return fmt.Errorf("unknown output format %q", output)
+		}
+		var req server.RangeRequest
+		var err error
+		if req.From, err = strconv.ParseUint(args[0], 10, 64); err != nil {
+			return fmt.Errorf("invalid height %q: %w", args[0], err)
+		}
+		if req.To, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+			return fmt.Errorf("invalid height %q: %w", args[1], err)
+		}
+		if nsString != "" {
+			if req.Namespace, err = celestia.ParseNamespace(nsString); err != nil {
+				return err
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+		defer cancel()
+		conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		client := server.NewRangeClient(conn)
+		get := client.GetIDs
+		if blobs {
+			get = client.GetAll
+		}
+
+		// follow the continuation cursor up to the end of the range, or the current head
+		for {
+			result, err := get(ctx, req)
+			if err != nil {
+				return err
+			}
+			for _, heightBlobs := range result.Heights {
+				if err := printResult(output, heightBlobs); err != nil {
+					return err
+				}
+			}
+			if result.Next == 0 || result.Next == req.From {
+				return nil
+			}
+			req.From = result.Next
+		}
+	},
+}
+
+var clientSubscribeCmd = &cobra.Command{
+	Use:          "subscribe",
+	Short:        "Print the IDs of blobs in the namespace at every new height, until interrupted",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		addr, _ := cmd.Flags().GetString(clientAddrFlag)
+		output, _ := cmd.Flags().GetString(clientOutputFlag)
+		nsString, _ := cmd.Flags().GetString(clientNamespaceFlag)
+		from, _ := cmd.Flags().GetUint64(clientFromFlag)
+		blobs, _ := cmd.Flags().GetBool(clientBlobsFlag)
+		if addr == "" {
+			return fmt.Errorf("--%s is required", clientAddrFlag)
+		}
+		if output != outputHex && output != outputBase64 && output != outputJSON {
+			return fmt.Errorf("unknown output format %q", output)
+		}
+		req := server.SubscribeRequest{FromHeight: from, Blobs: blobs}
+		if nsString != "" {
+			ns, err := celestia.ParseNamespace(nsString)
+			if err != nil {
+				return err
+			}
+			req.Namespace = ns
+		}
+
+		conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		err = server.NewSubscriptionClient(conn).Subscribe(cmd.Context(), req, func(result *celestia.HeightBlobs) error {
+			return printResult(output, result)
+		})
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		return err
+	},
+}
+
+var clientStatusCmd = &cobra.Command{
+	Use:          "status <ticket|pending id>",
+	Short:        "Get the status of a submission queued by a celestia-da service running with --" + grpcQueueFlag,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString(clientAddrFlag)
+		output, _ := cmd.Flags().GetString(clientOutputFlag)
+		timeout, _ := cmd.Flags().GetDuration(clientTimeoutFlag)
+		watch, _ := cmd.Flags().GetBool(clientWatchFlag)
+		if addr == "" {
+			return fmt.Errorf("--%s is required", clientAddrFlag)
+		}
+		if output != outputHex && output != outputBase64 && output != outputJSON {
+			return fmt.Errorf("unknown output format %q", output)
+		}
+		ticket, err := parseTicket(args[0])
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+		defer cancel()
+		conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		client := server.NewQueueClient(conn)
+
+		if !watch {
+			st, err := client.Status(ctx, ticket)
+			if err != nil {
+				return err
+			}
+			return printResult(output, st)
+		}
+		return client.Watch(ctx, ticket, func(st *celestia.SubmissionStatus) error {
+			return printResult(output, st)
+		})
+	},
+}
+
+// parseTicket accepts a hex encoded ticket, or a pending ID returned by Submit.
+func parseTicket(arg string) (string, error) {
+	values, err := decodeArgs([]string{arg})
+	if err != nil {
+		return "", err
+	}
+	if ticket, _, ok := celestia.TicketFromID(values[0]); ok {
+		return ticket, nil
+	}
+	if _, err := hex.DecodeString(arg); err == nil && len(arg) == 32 {
+		return arg, nil
+	}
+	return "", fmt.Errorf("%q is neither a ticket nor a pending ID", arg)
+}
+
+// clientRunFunc runs a single client request.
+type clientRunFunc func(ctx context.Context, client da.DA, ns da.Namespace, cmd *cobra.Command, args []string) (interface{}, error)
+
+func newClientCmd(use, short string, args cobra.PositionalArgs, run clientRunFunc) *cobra.Command {
+	return &cobra.Command{
+		Use:          use,
+		Short:        short,
+		Args:         args,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output, _ := cmd.Flags().GetString(clientOutputFlag)
+			if output != outputHex && output != outputBase64 && output != outputJSON {
+				return fmt.Errorf("unknown output format %q", output)
+			}
+			timeout, _ := cmd.Flags().GetDuration(clientTimeoutFlag)
+			ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+			defer cancel()
+
+			client, ns, closer, err := newDAClient(ctx, cmd)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			result, err := run(ctx, client, ns, cmd, args)
+			if err != nil {
+				return err
+			}
+			return printResult(output, result)
+		},
+	}
+}
+
+// newDAClient returns a DA client for the connection flags, the namespace to use for requests,
+// and a function releasing the connection.
+func newDAClient(ctx context.Context, cmd *cobra.Command) (da.DA, da.Namespace, func(), error) {
+	addr, _ := cmd.Flags().GetString(clientAddrFlag)
+	nodeAddr, _ := cmd.Flags().GetString(clientNodeAddrFlag)
+	nodeToken, _ := cmd.Flags().GetString(clientNodeTokenFlag)
+	nsString, _ := cmd.Flags().GetString(clientNamespaceFlag)
+	gasPrice, _ := cmd.Flags().GetFloat64(clientGasPriceFlag)
+
+	var ns da.Namespace
+	if nsString != "" {
+		namespace, err := celestia.ParseNamespace(nsString)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		ns = namespace
+	}
+
+	switch {
+	case addr != "" && nodeAddr != "":
+		return nil, nil, nil, fmt.Errorf("--%s and --%s are mutually exclusive", clientAddrFlag, clientNodeAddrFlag)
+	case addr != "":
+		client := proxygrpc.NewClient()
+		if err := client.Start(addr, grpc.WithTransportCredentials(insecure.NewCredentials())); err != nil {
+			return nil, nil, nil, err
+		}
+		return client, ns, func() { _ = client.Stop() }, nil
+	case nodeAddr != "":
+		if ns == nil {
+			return nil, nil, nil, fmt.Errorf("--%s is required with --%s", clientNamespaceFlag, clientNodeAddrFlag)
+		}
+		if nodeToken == "" {
+			nodeToken = os.Getenv(tokenEnvVar)
+		}
+		client, err := rpc.NewClient(ctx, nodeAddr, nodeToken)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return celestia.NewCelestiaDA(client, ns, gasPrice, ctx), ns, client.Close, nil
+	default:
+		return nil, nil, nil, fmt.Errorf("one of --%s or --%s is required", clientAddrFlag, clientNodeAddrFlag)
+	}
+}
+
+func runSubmit(ctx context.Context, client da.DA, ns da.Namespace, cmd *cobra.Command, args []string) (interface{}, error) {
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return nil, err
+	}
+	gasPrice, _ := cmd.Flags().GetFloat64(clientGasPriceFlag)
+	if key, _ := cmd.Flags().GetString(clientKeyFlag); key != "" {
+		ctx = server.WithSubmitKey(ctx, key)
+	}
+	if receipts, _ := cmd.Flags().GetBool(clientReceiptsFlag); !receipts {
+		return client.Submit(ctx, []da.Blob{data}, gasPrice, ns)
+	}
+	if c, ok := client.(*celestia.CelestiaDA); ok {
+		return c.SubmitWithReceipts(ctx, []da.Blob{data}, gasPrice, ns)
+	}
+	addr, _ := cmd.Flags().GetString(clientAddrFlag)
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return server.NewReceiptsClient(conn).Submit(ctx, server.SubmitRequest{Blobs: [][]byte{data}, GasPrice: gasPrice, Namespace: ns})
+}
+
+func runEstimateFee(ctx context.Context, client da.DA, ns da.Namespace, cmd *cobra.Command, args []string) (interface{}, error) {
+	blobs := make([][]byte, len(args))
+	for i, arg := range args {
+		var err error
+		if blobs[i], err = os.ReadFile(arg); err != nil {
+			return nil, err
+		}
+	}
+	if c, ok := client.(*celestia.CelestiaDA); ok {
+		return c.EstimateFee(ctx, blobs, ns)
+	}
+	addr, _ := cmd.Flags().GetString(clientAddrFlag)
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return server.NewFeeClient(conn).Estimate(ctx, server.EstimateFeeRequest{Blobs: blobs, Namespace: ns})
+}
+
+func runGet(ctx context.Context, client da.DA, ns da.Namespace, _ *cobra.Command, args []string) (interface{}, error) {
+	ids, err := decodeArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	return client.Get(ctx, ids, ns)
+}
+
+func runGetIDs(ctx context.Context, client da.DA, ns da.Namespace, _ *cobra.Command, args []string) (interface{}, error) {
+	height, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid height: %w", err)
+	}
+	return client.GetIDs(ctx, height, ns)
+}
+
+func runProofs(ctx context.Context, client da.DA, ns da.Namespace, _ *cobra.Command, args []string) (interface{}, error) {
+	ids, err := decodeArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetProofs(ctx, ids, ns)
+}
+
+func runValidate(ctx context.Context, client da.DA, ns da.Namespace, _ *cobra.Command, args []string) (interface{}, error) {
+	values, err := decodeArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	return client.Validate(ctx, []da.ID{values[0]}, []da.Proof{values[1]}, ns)
+}
+
+func runCommit(ctx context.Context, client da.DA, ns da.Namespace, _ *cobra.Command, args []string) (interface{}, error) {
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return client.Commit(ctx, []da.Blob{data}, ns)
+}
+
+// decodeArgs decodes hex or base64 encoded arguments, or reads them from a file if prefixed with @.
+func decodeArgs(args []string) ([][]byte, error) {
+	values := make([][]byte, len(args))
+	for i, arg := range args {
+		if path, ok := strings.CutPrefix(arg, "@"); ok {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = data
+			continue
+		}
+		if b, err := hex.DecodeString(strings.TrimPrefix(arg, "0x")); err == nil {
+			values[i] = b
+			continue
+		}
+		b, err := base64.StdEncoding.DecodeString(arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q is neither hex nor base64 encoded", arg)
+		}
+		values[i] = b
+	}
+	return values, nil
+}
+
+// printResult prints one value per line, or the whole result as JSON.
+func printResult(output string, result interface{}) error {
+	if output == outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	switch values := result.(type) {
+	case [][]byte:
+		for _, v := range values {
+			if output == outputBase64 {
+				fmt.Println(base64.StdEncoding.EncodeToString(v))
+			} else {
+				fmt.Println(hex.EncodeToString(v))
+			}
+		}
+	case []bool:
+		for _, v := range values {
+			fmt.Println(v)
+		}
+	case *celestia.HeightBlobs:
+		fmt.Println("Height:", values.Height)
+		for i, id := range values.IDs {
+			if output == outputBase64 {
+				fmt.Println("ID:    ", base64.StdEncoding.EncodeToString(id))
+			} else {
+				fmt.Println("ID:    ", hex.EncodeToString(id))
+			}
+			if i < len(values.Blobs) {
+				if output == outputBase64 {
+					fmt.Println("Blob:  ", base64.StdEncoding.EncodeToString(values.Blobs[i]))
+				} else {
+					fmt.Println("Blob:  ", hex.EncodeToString(values.Blobs[i]))
+				}
+			}
+		}
+	case *celestia.CommitmentResult:
+		encode := hex.EncodeToString
+		if output == outputBase64 {
+			encode = base64.StdEncoding.EncodeToString
+		}
+		fmt.Println("ID:   ", encode(values.ID))
+		fmt.Println("Blob: ", encode(values.Blob))
+		fmt.Println("Proof:", encode(values.Proof))
+	case *celestia.IndexEntry:
+		encode := hex.EncodeToString
+		if output == outputBase64 {
+			encode = base64.StdEncoding.EncodeToString
+		}
+		fmt.Println("ID:        ", encode(values.ID()))
+		fmt.Println("Height:    ", values.Height)
+		fmt.Println("Namespace: ", encode(values.Namespace))
+		fmt.Println("Commitment:", encode(values.Commitment))
+		fmt.Println("Size:      ", values.Size)
+		fmt.Println("Tx size:   ", values.TxSize)
+		fmt.Println("Gas price: ", values.GasPrice)
+		fmt.Println("Time:      ", values.Time.Format(time.RFC3339))
+		if values.Caller != "" {
+			fmt.Println("Caller:    ", values.Caller)
+		}
+		if values.TxHash != "" {
+			fmt.Println("Tx hash:   ", values.TxHash)
+			fmt.Println("Fee:       ", values.Fee)
+			fmt.Println("Gas used:  ", values.GasUsed)
+		}
+		if values.Shares != nil {
+			fmt.Printf("Shares:     %d-%d\n", values.Shares.Start, values.Shares.End)
+		}
+	case *celestia.FeeEstimate:
+		fmt.Println("Transactions:       ", values.Transactions)
+		fmt.Println("Shares:             ", values.Shares)
+		fmt.Println("Gas:                ", values.Gas)
+		fmt.Println("Gas price:          ", values.GasPrice)
+		fmt.Println("Fee:                ", values.Fee)
+		fmt.Println("Suggested gas price:", values.SuggestedGasPrice)
+		fmt.Println("Suggested fee:      ", values.SuggestedFee)
+	case *server.UsageResponse:
+		for _, usage := range []struct {
+			scope string
+			usage *server.Usage
+		}{{"Client", values.Client}, {"Namespace", values.Namespace}} {
+			if usage.usage == nil {
+				continue
+			}
+			fmt.Printf("%s usage on %s: %d bytes (limit %d), %d utia (limit %d)\n", usage.scope, usage.usage.Day,
+				usage.usage.Bytes, usage.usage.Limit.DailyBytes, usage.usage.Fee, usage.usage.Limit.DailyFee)
+		}
+	case *server.ConfigResponse:
+		// the configuration is nested
+		return printResult(outputJSON, values)
+	case *server.FlushCacheResponse:
+		fmt.Println("Flushed cache entries:", values.Entries)
+	case *server.QueueResponse:
+		fmt.Println("Paused: ", values.Paused)
+		fmt.Println("Pending:", len(values.Pending))
+		for _, status := range values.Pending {
+			fmt.Printf("%s created %s, %d attempts", status.Ticket, status.Created.Format(time.RFC3339), status.Attempts)
+			if status.Error != "" {
+				fmt.Printf(", last error: %s", status.Error)
+			}
+			fmt.Println()
+		}
+	case *celestia.Balance:
+		fmt.Println("Balance:", values.Amount, values.Denom)
+		fmt.Println("Updated:", values.Updated.Format(time.RFC3339))
+		if values.Low {
+			fmt.Println("Low:    ", values.Low)
+		}
+	case *celestia.SubmitResult:
+		// receipts have too many fields for one value per line
+		return printResult(outputJSON, values)
+	case *celestia.SubmissionStatus:
+		fmt.Println("Ticket:  ", values.Ticket)
+		fmt.Println("State:   ", values.State)
+		fmt.Println("Attempts:", values.Attempts)
+		if values.Error != "" {
+			fmt.Println("Error:   ", values.Error)
+		}
+		for _, id := range values.IDs {
+			if output == outputBase64 {
+				fmt.Println("ID:      ", base64.StdEncoding.EncodeToString(id))
+			} else {
+				fmt.Println("ID:      ", hex.EncodeToString(id))
+			}
+		}
+	default:
+		return errors.New("unsupported result type")
+	}
+	return nil
+}
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"fmt"
+	"path/filepath"
+
 	cmdnode "github.com/celestiaorg/celestia-node/cmd"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+
+	"github.com/rollkit/celestia-da/celestia"
+	"github.com/rollkit/celestia-da/server"
 )
 
 const (
@@ -13,20 +19,47 @@ const (
 	grpcListenFlag    = "da.grpc.listen"
 	grpcNetworkFlag   = "da.grpc.network"
 	grpcGasPriceFlag  = "da.grpc.gasprice"
+
+	grpcTokenFileFlag    = "da.grpc.token.file" // #nosec G101
+	grpcCreateSecretFlag = "da.grpc.token.create-secret"
+	grpcReadOnlyFlag     = "da.grpc.readonly"
+
+	grpcCompressionFlag = "da.grpc.compression"
+	grpcEncryptionFlag  = "da.grpc.encryption"
+	grpcChunkingFlag    = "da.grpc.chunking"
+
+	grpcQueueFlag         = "da.grpc.queue"
+	grpcQueueAttemptsFlag = "da.grpc.queue.attempts"
+
+	grpcCacheFlag     = "da.grpc.cache"
+	grpcCacheDiskFlag = "da.grpc.cache.disk"
+
+	grpcIndexFlag = "da.grpc.index"
+
+	grpcFinalityTimeoutFlag = "da.grpc.finality.timeout"
+	grpcFinalityDepthFlag   = "da.grpc.finality.depth"
+
+	grpcBalanceIntervalFlag = "da.grpc.balance.interval"
+	grpcBalanceWarnFlag     = "da.grpc.balance.warn"
+	grpcBalanceRefuseFlag   = "da.grpc.balance.refuse"
+
+	grpcLimitsFlag = "da.grpc.limits"
+
+	grpcDedupTTLFlag    = "da.grpc.dedup.ttl"
+	grpcDedupDeriveFlag = "da.grpc.dedup.derive"
+
+	grpcAdminTokenFileFlag = "da.grpc.admin.token.file" // #nosec G101
+
+	grpcUpstreamStrategyFlag = "da.grpc.upstream.strategy"
+	grpcUpstreamHealthFlag   = "da.grpc.upstream.health-interval"
+	grpcUpstreamMaxLagFlag   = "da.grpc.upstream.max-lag"
+	grpcUpstreamAttemptsFlag = "da.grpc.upstream.read-attempts"
 )
 
 // WithDataAvailabilityService patches the start command to also run the gRPC Data Availability service
 func WithDataAvailabilityService(flags []*pflag.FlagSet) func(*cobra.Command) {
 	return func(c *cobra.Command) {
-		grpcFlags := &pflag.FlagSet{}
-		grpcFlags.String(grpcAddrFlag, "http://127.0.0.1:26658", "celestia-node RPC endpoint address")
-		grpcFlags.String(grpcTokenFlag, "", "celestia-node RPC auth token")
-		grpcFlags.String(grpcNamespaceFlag, "", "celestia namespace to use (hex encoded) [Deprecated]")
-		grpcFlags.String(grpcListenFlag, "127.0.0.1:0", "gRPC service listen address")
-		grpcFlags.String(grpcNetworkFlag, "tcp", "gRPC service listen network type must be \"tcp\", \"tcp4\", \"tcp6\", \"unix\" or \"unixpacket\"")
-		grpcFlags.Float64(grpcGasPriceFlag, -1, "gas price for estimating fee (utia/gas) default: -1 for default fees")
-
-		fset := append(flags, grpcFlags)
+		fset := append(flags, daFlags(true))
 
 		for _, set := range fset {
 			c.Flags().AddFlagSet(set)
@@ -37,26 +70,204 @@ func WithDataAvailabilityService(flags []*pflag.FlagSet) func(*cobra.Command) {
 		}
 
 		preRun := func(cmd *cobra.Command, args []string) {
-			// Extract gRPC service flags
-			rpcAddress, _ := cmd.Flags().GetString(grpcAddrFlag)
-			rpcToken, _ := cmd.Flags().GetString(grpcTokenFlag)
-			nsString, _ := cmd.Flags().GetString(grpcNamespaceFlag)
-			listenAddress, _ := cmd.Flags().GetString(grpcListenFlag)
-			listenNetwork, _ := cmd.Flags().GetString(grpcNetworkFlag)
-			gasPrice, _ := cmd.Flags().GetFloat64(grpcGasPriceFlag)
-
-			if rpcToken == "" {
-				token, err := authToken(cmdnode.StorePath(c.Context()))
-				if err != nil {
-					log.Fatal(err)
-				}
-				rpcToken = token
+			storePath := cmdnode.StorePath(c.Context())
+			cfg, err := newServeConfig(cmd.Flags(), serveDirs{
+				store:    storePath,
+				keystore: storePath,
+				data:     filepath.Join(storePath, "celestia-da"),
+			})
+			if err != nil {
+				log.Fatal(err)
 			}
 
 			// serve the gRPC service in a goroutine
-			go serve(cmd.Context(), rpcAddress, rpcToken, listenAddress, listenNetwork, nsString, gasPrice)
+			go serve(cmd.Context(), cfg)
 		}
 
 		c.PreRun = preRun
 	}
 }
+
+// daFlags returns the flags of the gRPC service. The flags generating an auth token from the node
+// store are only included if the service runs within the node.
+func daFlags(nodeStore bool) *pflag.FlagSet {
+	grpcFlags := &pflag.FlagSet{}
+	grpcFlags.StringSlice(grpcAddrFlag, []string{"http://127.0.0.1:26658"}, "celestia-node RPC endpoint addresses, the first healthy one is the primary receiving submissions")
+	grpcFlags.String(grpcUpstreamStrategyFlag, string(celestia.StrategyRoundRobin), "how reads are spread over healthy endpoints: \"round-robin\" or \"latency\"")
+	grpcFlags.Duration(grpcUpstreamHealthFlag, celestia.DefaultUpstreamConfig().HealthInterval, "interval between health checks of the celestia-node endpoints")
+	grpcFlags.Uint64(grpcUpstreamMaxLagFlag, 0, "consider endpoints more than this many heights behind the highest head unhealthy, 0 disables the check")
+	grpcFlags.Int(grpcUpstreamAttemptsFlag, celestia.DefaultUpstreamConfig().ReadAttempts, "number of attempts of reads while no celestia-node endpoint is reachable, submissions are never retried")
+	grpcFlags.String(grpcTokenFlag, "", "celestia-node RPC auth token (visible in process listings, prefer --"+grpcTokenFileFlag+" or $"+tokenEnvVar+")")
+	grpcFlags.String(grpcTokenFileFlag, "", "path to a file containing the celestia-node RPC auth token")
+	if nodeStore {
+		grpcFlags.Bool(grpcCreateSecretFlag, false, "create and persist a new JWT secret if the node store has none")
+		grpcFlags.Bool(grpcReadOnlyFlag, false, "request a read-only auth token, blob submission will be rejected")
+	}
+	grpcFlags.Bool(grpcEncryptionFlag, false, "encrypt blobs in namespaces with encryption keys in the node keystore, see \"celestia-da encryption\"")
+	grpcFlags.Bool(grpcChunkingFlag, false, "submit blobs larger than the max blob size as chunks, returning the ID of a manifest referencing them")
+	grpcFlags.Bool(grpcQueueFlag, false, "submit blobs asynchronously: queue them in a write-ahead log on disk and return pending IDs")
+	grpcFlags.Int(grpcQueueAttemptsFlag, 0, "number of attempts before a queued submission fails, 0 retries forever")
+	grpcFlags.Int64(grpcCacheFlag, 0, "size of the in-memory cache of retrieved blobs, IDs and proofs in MiB, 0 disables caching")
+	grpcFlags.Int64(grpcCacheDiskFlag, 0, "size of the on-disk cache tier in MiB, 0 disables it")
+	grpcFlags.Duration(grpcFinalityTimeoutFlag, 0, "wait up to this long after inclusion until submitted blobs are retrievable and their proofs valid, 0 returns on inclusion")
+	grpcFlags.Uint64(grpcFinalityDepthFlag, 0, "number of heights following the inclusion height to wait for with --"+grpcFinalityTimeoutFlag)
+	grpcFlags.Duration(grpcBalanceIntervalFlag, celestia.DefaultBalanceConfig().Interval, "interval between queries of the node account balance, 0 disables balance monitoring")
+	grpcFlags.Uint64(grpcBalanceWarnFlag, 0, "log warnings while the node account balance is below this amount of utia, 0 disables warnings")
+	grpcFlags.Bool(grpcBalanceRefuseFlag, false, "refuse submissions whose estimated fee exceeds the node account balance")
+	grpcFlags.String(grpcLimitsFlag, "", "path to a JSON file with per-client and per-namespace rate limits and daily quotas")
+	grpcFlags.Duration(grpcDedupTTLFlag, server.DefaultDedupConfig().TTL, "how long submissions are remembered to answer retries with the same idempotency key, 0 disables deduplication")
+	grpcFlags.Bool(grpcDedupDeriveFlag, false, "deduplicate submissions without idempotency key by their namespace and blob commitments")
+	grpcFlags.String(grpcAdminTokenFileFlag, "", "path to a file containing the token authenticating admin operations (default $"+adminTokenEnvVar+"), admin operations are disabled without it")
+	grpcFlags.Bool(grpcIndexFlag, false, "record submitted blobs in an on-disk index, see \"celestia-da client index\"")
+	grpcFlags.String(grpcCompressionFlag, "", "compress blobs before submission: \"gzip\", \"zstd\", or \"none\" to only decompress retrieved blobs")
+	grpcFlags.String(grpcNamespaceFlag, "", "celestia namespace to use (hex or base64 encoded, 10 byte version 0 ID or full 29 byte namespace) [Deprecated]")
+	grpcFlags.String(grpcListenFlag, "127.0.0.1:0", "gRPC service listen address")
+	grpcFlags.String(grpcNetworkFlag, "tcp", "gRPC service listen network type must be \"tcp\", \"tcp4\", \"tcp6\", \"unix\" or \"unixpacket\"")
+	grpcFlags.Float64(grpcGasPriceFlag, -1, "gas price for estimating fee (utia/gas) default: -1 for default fees")
+	return grpcFlags
+}
+
+// serveDirs locates the files of the gRPC service.
+type serveDirs struct {
+	// store is the node store auth tokens are generated from, empty if the node runs elsewhere.
+	store string
+	// keystore is the directory containing the keystore with the encryption keys.
+	keystore string
+	// data is the directory of the queue, on-disk cache, index and rate limit usage.
+	data string
+}
+
+// newServeConfig returns the configuration of the gRPC service given by flags.
+func newServeConfig(flags *pflag.FlagSet, dirs serveDirs) (serveConfig, error) {
+	// Extract gRPC service flags
+	rpcAddresses, _ := flags.GetStringSlice(grpcAddrFlag)
+	rpcToken, _ := flags.GetString(grpcTokenFlag)
+	nsString, _ := flags.GetString(grpcNamespaceFlag)
+	listenAddress, _ := flags.GetString(grpcListenFlag)
+	listenNetwork, _ := flags.GetString(grpcNetworkFlag)
+	gasPrice, _ := flags.GetFloat64(grpcGasPriceFlag)
+
+	if rpcToken == "" {
+		// the token generation flags are only defined with a node store
+		tokenFile, _ := flags.GetString(grpcTokenFileFlag)
+		createSecret, _ := flags.GetBool(grpcCreateSecretFlag)
+		readOnly, _ := flags.GetBool(grpcReadOnlyFlag)
+		token, err := resolveToken(dirs.store, tokenOptions{
+			file:         tokenFile,
+			readOnly:     readOnly,
+			createSecret: createSecret,
+		})
+		if err != nil {
+			return serveConfig{}, err
+		}
+		rpcToken = token
+	}
+
+	upstream := celestia.DefaultUpstreamConfig()
+	for _, address := range rpcAddresses {
+		upstream.Endpoints = append(upstream.Endpoints, celestia.Endpoint{Address: address, Token: rpcToken})
+	}
+	strategyName, _ := flags.GetString(grpcUpstreamStrategyFlag)
+	strategy, err := celestia.ParseStrategy(strategyName)
+	if err != nil {
+		return serveConfig{}, err
+	}
+	upstream.Strategy = strategy
+	upstream.HealthInterval, _ = flags.GetDuration(grpcUpstreamHealthFlag)
+	upstream.MaxLag, _ = flags.GetUint64(grpcUpstreamMaxLagFlag)
+	upstream.ReadAttempts, _ = flags.GetInt(grpcUpstreamAttemptsFlag)
+
+	var opts []celestia.Option
+	if compression, _ := flags.GetString(grpcCompressionFlag); compression != "" {
+		algorithm, err := celestia.ParseCompression(compression)
+		if err != nil {
+			return serveConfig{}, err
+		}
+		opts = append(opts, celestia.WithCompression(algorithm))
+	}
+	if encryption, _ := flags.GetBool(grpcEncryptionFlag); encryption {
+		ks, err := newKeystore(dirs.keystore)
+		if err != nil {
+			return serveConfig{}, err
+		}
+		keyring, count, err := loadKeyring(ks)
+		if err != nil {
+			return serveConfig{}, err
+		}
+		if count == 0 {
+			return serveConfig{}, fmt.Errorf("--%s is set, but there are no encryption keys in %s", grpcEncryptionFlag, ks.Path())
+		}
+		log.Infow("loaded blob encryption keys", "count", count)
+		opts = append(opts, celestia.WithEncryption(keyring))
+	}
+	if chunking, _ := flags.GetBool(grpcChunkingFlag); chunking {
+		opts = append(opts, celestia.WithChunking(0))
+	}
+	if cacheSize, _ := flags.GetInt64(grpcCacheFlag); cacheSize > 0 {
+		config := celestia.DefaultCacheConfig()
+		config.MaxBytes = cacheSize << 20
+		if diskSize, _ := flags.GetInt64(grpcCacheDiskFlag); diskSize > 0 {
+			config.Dir = filepath.Join(dirs.data, "cache")
+			config.MaxDiskBytes = diskSize << 20
+		}
+		opts = append(opts, celestia.WithCache(config))
+	}
+	if timeout, _ := flags.GetDuration(grpcFinalityTimeoutFlag); timeout > 0 {
+		config := celestia.FinalityConfig{Timeout: timeout}
+		config.Depth, _ = flags.GetUint64(grpcFinalityDepthFlag)
+		opts = append(opts, celestia.WithFinality(config))
+	}
+	if interval, _ := flags.GetDuration(grpcBalanceIntervalFlag); interval > 0 {
+		config := celestia.BalanceConfig{Interval: interval}
+		config.WarnBelow, _ = flags.GetUint64(grpcBalanceWarnFlag)
+		config.Refuse, _ = flags.GetBool(grpcBalanceRefuseFlag)
+		opts = append(opts, celestia.WithBalanceMonitor(config))
+	}
+	if index, _ := flags.GetBool(grpcIndexFlag); index {
+		opts = append(opts, celestia.WithIndex(filepath.Join(dirs.data, "index")))
+	}
+
+	var queue *celestia.QueueConfig
+	if async, _ := flags.GetBool(grpcQueueFlag); async {
+		config := celestia.DefaultQueueConfig(filepath.Join(dirs.data, "queue"))
+		config.MaxAttempts, _ = flags.GetInt(grpcQueueAttemptsFlag)
+		queue = &config
+	}
+
+	var limits *server.LimitsConfig
+	if path, _ := flags.GetString(grpcLimitsFlag); path != "" {
+		config, err := server.LoadLimitsConfig(path)
+		if err != nil {
+			return serveConfig{}, err
+		}
+		config.Dir = filepath.Join(dirs.data, "limits")
+		limits = &config
+	}
+
+	var dedup *server.DedupConfig
+	if ttl, _ := flags.GetDuration(grpcDedupTTLFlag); ttl > 0 {
+		config := server.DefaultDedupConfig()
+		config.TTL = ttl
+		config.Derive, _ = flags.GetBool(grpcDedupDeriveFlag)
+		dedup = &config
+	}
+
+	adminTokenFile, _ := flags.GetString(grpcAdminTokenFileFlag)
+	adminToken, err := adminToken(adminTokenFile)
+	if err != nil {
+		return serveConfig{}, err
+	}
+
+	return serveConfig{
+		upstream:      upstream,
+		listenAddress: listenAddress,
+		listenNetwork: listenNetwork,
+		namespace:     nsString,
+		gasPrice:      gasPrice,
+		queue:         queue,
+		limits:        limits,
+		dedup:         dedup,
+		adminToken:    adminToken,
+		options:       opts,
+	}, nil
+}
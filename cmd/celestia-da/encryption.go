@@ -0,0 +1,147 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/celestiaorg/celestia-node/libs/keystore"
+	"github.com/spf13/cobra"
+
+	"github.com/rollkit/celestia-da/celestia"
+)
+
+// encryptionKeyPrefix prefixes the names of blob encryption keys in the node keystore, which are
+// named da-encryption-<namespace hex>-<key ID>.
+const encryptionKeyPrefix = "da-encryption-"
+
+const (
+	encryptionNamespaceFlag = "namespace"
+	encryptionStoreFlag     = "node.store"
+)
+
+var encryptionCmd = &cobra.Command{
+	Use:   "encryption",
+	Short: "Manage blob encryption keys",
+	Args:  cobra.NoArgs,
+}
+
+var encryptionNewKeyCmd = &cobra.Command{
+	Use:   "new-key",
+	Short: "Generate a new encryption key for a namespace, new blobs are encrypted with the newest key",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		nsString, _ := cmd.Flags().GetString(encryptionNamespaceFlag)
+		storePath, _ := cmd.Flags().GetString(encryptionStoreFlag)
+		ns, err := celestia.ParseNamespace(nsString)
+		if err != nil {
+			return err
+		}
+		ks, err := newKeystore(storePath)
+		if err != nil {
+			return err
+		}
+		id, err := newEncryptionKey(ks, ns)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created encryption key %d for namespace %s\n", id, hex.EncodeToString(ns))
+		return nil
+	},
+}
+
+func init() {
+	encryptionNewKeyCmd.Flags().String(encryptionNamespaceFlag, "", "namespace to encrypt (hex or base64 encoded)")
+	encryptionNewKeyCmd.Flags().String(encryptionStoreFlag, "", "path to the node store containing the keystore")
+	for _, flag := range []string{encryptionNamespaceFlag, encryptionStoreFlag} {
+		if err := encryptionNewKeyCmd.MarkFlagRequired(flag); err != nil {
+			log.Fatal(flag, err)
+		}
+	}
+	encryptionCmd.AddCommand(encryptionNewKeyCmd)
+}
+
+func encryptionKeyName(ns []byte, id uint32) keystore.KeyName {
+	return keystore.KeyName(fmt.Sprintf("%s%s-%d", encryptionKeyPrefix, hex.EncodeToString(ns), id))
+}
+
+// parseEncryptionKeyName returns the namespace and key ID of an encryption key, or false for other keys.
+func parseEncryptionKeyName(name keystore.KeyName) ([]byte, uint32, bool) {
+	rest, ok := strings.CutPrefix(string(name), encryptionKeyPrefix)
+	if !ok {
+		return nil, 0, false
+	}
+	nsHex, idString, ok := strings.Cut(rest, "-")
+	if !ok {
+		return nil, 0, false
+	}
+	ns, err := hex.DecodeString(nsHex)
+	if err != nil {
+		return nil, 0, false
+	}
+	id, err := strconv.ParseUint(idString, 10, 32)
+	if err != nil {
+		return nil, 0, false
+	}
+	return ns, uint32(id), true
+}
+
+// loadKeyring loads all blob encryption keys from the keystore.
+func loadKeyring(ks keystore.Keystore) (*celestia.Keyring, int, error) {
+	names, err := ks.List()
+	if err != nil {
+		return nil, 0, err
+	}
+	keyring := celestia.NewKeyring()
+	count := 0
+	for _, name := range names {
+		nsBytes, id, ok := parseEncryptionKeyName(name)
+		if !ok {
+			continue
+		}
+		ns, err := celestia.NamespaceFromBytes(nsBytes)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid namespace in encryption key %s: %w", name, err)
+		}
+		key, err := ks.Get(name)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := keyring.Add(ns, id, key.Body); err != nil {
+			return nil, 0, fmt.Errorf("invalid encryption key %s: %w", name, err)
+		}
+		count++
+	}
+	return keyring, count, nil
+}
+
+// newEncryptionKey generates a key with an ID higher than all existing keys for the namespace.
+func newEncryptionKey(ks keystore.Keystore, ns []byte) (uint32, error) {
+	names, err := ks.List()
+	if err != nil {
+		return 0, err
+	}
+	var id uint32
+	for _, name := range names {
+		keyNs, keyID, ok := parseEncryptionKeyName(name)
+		if ok && string(keyNs) == string(ns) && keyID >= id {
+			id = keyID + 1
+		}
+	}
+	if id == 0 {
+		id = 1
+	}
+
+	body, err := io.ReadAll(io.LimitReader(rand.Reader, celestia.EncryptionKeySize))
+	if err != nil {
+		return 0, err
+	}
+	if len(body) != celestia.EncryptionKeySize {
+		return 0, errors.New("failed to generate encryption key")
+	}
+	return id, ks.Put(encryptionKeyName(ns, id), keystore.PrivKey{Body: body})
+}
@@ -31,7 +31,7 @@ func init() {
 	bridgeCmd := cmdnode.NewBridge(WithSubcommands())
 	lightCmd := cmdnode.NewLight(WithSubcommands())
 	fullCmd := cmdnode.NewFull(WithSubcommands())
-	rootCmd.AddCommand(lightCmd, bridgeCmd, fullCmd, versionCmd)
+	rootCmd.AddCommand(lightCmd, bridgeCmd, fullCmd, serveCmd, versionCmd, namespaceCmd, clientCmd, encryptionCmd)
 }
 
 func main() {
@@ -47,7 +47,7 @@ func run() error {
 }
 
 var rootCmd = &cobra.Command{
-	Use: "celestia-da [  bridge  ||  full ||  light  ] [subcommand]",
+	Use: "celestia-da [  bridge  ||  full ||  light  ] [subcommand] | serve",
 	Short: `
 	    ____      __          __  _
 	  / ____/__  / /__  _____/ /_(_)___ _
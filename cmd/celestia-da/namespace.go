@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rollkit/celestia-da/celestia"
+)
+
+const (
+	chainIDFlag = "chain-id"
+	saltFlag    = "salt"
+)
+
+var namespaceCmd = &cobra.Command{
+	Use:   "namespace",
+	Short: "Derive and inspect celestia namespaces",
+	Args:  cobra.NoArgs,
+}
+
+var namespaceDeriveCmd = &cobra.Command{
+	Use:   "derive",
+	Short: "Deterministically derive a version 0 namespace from a rollup chain ID",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		chainID, _ := cmd.Flags().GetString(chainIDFlag)
+		salt, _ := cmd.Flags().GetString(saltFlag)
+		ns, err := celestia.DeriveNamespace(chainID, []byte(salt))
+		if err != nil {
+			return err
+		}
+		printNamespace(ns)
+		return nil
+	},
+}
+
+var namespaceInspectCmd = &cobra.Command{
+	Use:   "inspect <namespace>",
+	Short: "Decode a hex or base64 encoded namespace and check that it can be used for blobs",
+	Args:  cobra.ExactArgs(1),
+	// validation errors are the expected output, don't bury them under the usage text
+	SilenceUsage: true,
+	RunE: func(_ *cobra.Command, args []string) error {
+		raw, err := celestia.DecodeNamespace(strings.TrimSpace(args[0]))
+		if err != nil {
+			return err
+		}
+		ns, err := celestia.NamespaceFromBytes(raw)
+		if err != nil {
+			fmt.Printf("Raw: %s (%d bytes)\n", hex.EncodeToString(raw), len(raw))
+			return err
+		}
+		printNamespace(ns)
+		return nil
+	},
+}
+
+func init() {
+	namespaceDeriveCmd.Flags().String(chainIDFlag, "", "rollup chain ID")
+	namespaceDeriveCmd.Flags().String(saltFlag, "", "optional salt, allows deriving multiple namespaces per chain")
+	if err := namespaceDeriveCmd.MarkFlagRequired(chainIDFlag); err != nil {
+		log.Fatal(chainIDFlag, err)
+	}
+	namespaceCmd.AddCommand(namespaceDeriveCmd, namespaceInspectCmd)
+}
+
+func printNamespace(ns []byte) {
+	fmt.Printf("Namespace: %s\n", hex.EncodeToString(ns))
+	fmt.Printf("Base64: %s\n", base64.StdEncoding.EncodeToString(ns))
+	fmt.Printf("Version: %d\n", ns[0])
+	fmt.Printf("ID: %s\n", hex.EncodeToString(ns[1:]))
+	if ns[0] == 0 {
+		// the short form accepted by --da.grpc.namespace
+		fmt.Printf("Version 0 ID: %s\n", hex.EncodeToString(ns[len(ns)-10:]))
+	}
+}
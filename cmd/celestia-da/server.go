@@ -2,52 +2,163 @@ package main
 
 import (
 	"context"
-	"encoding/hex"
 	"errors"
 	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 
-	rpc "github.com/celestiaorg/celestia-node/api/rpc/client"
-	"github.com/celestiaorg/celestia-node/share"
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
 
 	"github.com/rollkit/celestia-da/celestia"
+	"github.com/rollkit/celestia-da/server"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
-
-	proxygrpc "github.com/rollkit/go-da/proxy/grpc"
 )
 
-func serve(ctx context.Context, rpcAddress, rpcToken, listenAddress, listenNetwork, nsString string, gasPrice float64) {
-	client, err := rpc.NewClient(ctx, rpcAddress, rpcToken)
+// grpcDataDirFlag is the directory of the files of the standalone gRPC service.
+const grpcDataDirFlag = "da.grpc.data"
+
+// serveCmd runs the gRPC service without a node, connecting to the RPC endpoints of nodes
+// running elsewhere.
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the gRPC Data Availability service for celestia-node RPC endpoints running elsewhere",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dataDir, _ := cmd.Flags().GetString(grpcDataDirFlag)
+		dataDir, err := homedir.Expand(filepath.Clean(dataDir))
+		if err != nil {
+			return err
+		}
+		cfg, err := newServeConfig(cmd.Flags(), serveDirs{keystore: dataDir, data: dataDir})
+		if err != nil {
+			return err
+		}
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		serve(ctx, cfg)
+		return nil
+	},
+}
+
+func init() {
+	serveCmd.Flags().AddFlagSet(daFlags(false))
+	serveCmd.Flags().String(grpcDataDirFlag, "~/.celestia-da", "directory of the queue, on-disk cache, index, rate limit usage and encryption keystore")
+	if err := serveCmd.MarkFlagRequired(grpcNamespaceFlag); err != nil {
+		log.Fatal(grpcNamespaceFlag, err)
+	}
+}
+
+// serveConfig configures the gRPC service.
+type serveConfig struct {
+	upstream      celestia.UpstreamConfig
+	listenAddress string
+	listenNetwork string
+	namespace     string
+	gasPrice      float64
+	// queue enables asynchronous submission, nil submits synchronously.
+	queue *celestia.QueueConfig
+	// limits enables rate limits and quotas, nil disables them.
+	limits *server.LimitsConfig
+	// dedup enables idempotent submissions, nil disables them.
+	dedup *server.DedupConfig
+	// adminToken enables the admin operations changing the service, see server.AdminConfig.
+	adminToken string
+	options    []celestia.Option
+}
+
+// serve serves the gRPC service until ctx is done.
+func serve(ctx context.Context, cfg serveConfig) {
+	namespace, err := celestia.ParseNamespace(cfg.namespace)
 	if err != nil {
-		log.Fatalln("failed to create celestia-node RPC client:", err)
+		log.Fatalln("invalid namespace:", err)
 	}
-	nsBytes := make([]byte, len(nsString)/2)
-	_, err = hex.Decode(nsBytes, []byte(nsString))
+	upstream, err := celestia.NewUpstream(ctx, cfg.upstream)
 	if err != nil {
-		log.Fatalln("invalid hex value of a namespace:", err)
+		log.Fatalln("failed to create celestia-node RPC client:", err)
 	}
-	namespace, err := share.NewBlobNamespaceV0(nsBytes)
+	defer upstream.Close()
+
+	da, err := celestia.NewCelestiaDAWithOptions(upstream.Client(), namespace, cfg.gasPrice, ctx, cfg.options...)
 	if err != nil {
-		log.Fatalln("invalid namespace:", err)
+		log.Fatalln("failed to configure celestia-da:", err)
 	}
+	defer func() {
+		if err := da.Close(); err != nil {
+			log.Errorln("failed to close celestia-da:", err)
+		}
+	}()
 
-	da := celestia.NewCelestiaDA(client, namespace, gasPrice, ctx)
-	// TODO(tzdybal): add configuration options for encryption
-	srv := proxygrpc.NewServer(da, grpc.Creds(insecure.NewCredentials()))
+	interceptors := []grpc.UnaryServerInterceptor{server.CallerInterceptor, server.UnaryServerInterceptor}
+	if cfg.dedup != nil {
+		interceptors = append(interceptors, server.NewDeduplicator(da, *cfg.dedup).UnaryServerInterceptor)
+	}
+	var streamInterceptors []grpc.StreamServerInterceptor
+	var limiter *server.Limiter
+	if cfg.limits != nil {
+		limiter, err = server.NewLimiter(da, *cfg.limits)
+		if err != nil {
+			log.Fatalln("failed to configure rate limits:", err)
+		}
+		defer func() {
+			if err := limiter.Close(); err != nil {
+				log.Errorln("failed to close rate limiter:", err)
+			}
+		}()
+		interceptors = append(interceptors, limiter.UnaryServerInterceptor)
+		streamInterceptors = append(streamInterceptors, limiter.StreamServerInterceptor)
+	}
+	opts := []grpc.ServerOption{
+		grpc.Creds(insecure.NewCredentials()),
+		grpc.ChainUnaryInterceptor(interceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+	}
+	var srv *grpc.Server
+	var queue *celestia.Queue
+	if cfg.queue != nil {
+		queue, err = celestia.NewQueue(da, *cfg.queue)
+		if err != nil {
+			log.Fatalln("failed to open submission queue:", err)
+		}
+		defer func() {
+			if err := queue.Close(); err != nil {
+				log.Errorln("failed to close submission queue:", err)
+			}
+		}()
+		log.Infoln("submitting blobs asynchronously, queue:", cfg.queue.Dir)
+		srv = server.NewDAServer(queue, opts...)
+		server.RegisterQueueService(srv, queue)
+	} else {
+		srv = server.NewDAServer(da, opts...)
+	}
+	server.RegisterSubscriptionService(srv, da)
+	server.RegisterRangeService(srv, da)
+	server.RegisterIndexService(srv, da)
+	server.RegisterCommitmentService(srv, da)
+	server.RegisterReceiptsService(srv, da)
+	server.RegisterFeeService(srv, da)
+	server.RegisterAdminService(srv, da, server.AdminConfig{Token: cfg.adminToken, Limiter: limiter, Queue: queue})
 
-	lis, err := net.Listen(listenNetwork, listenAddress)
+	lis, err := net.Listen(cfg.listenNetwork, cfg.listenAddress)
 	if err != nil {
 		log.Fatalln("failed to create network listener:", err)
 	}
 	defer func() {
-		if err := lis.Close(); err != nil {
+		if err := lis.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
 			log.Errorln("failed to close network listener:", err)
 		}
 	}()
+	go func() {
+		<-ctx.Done()
+		srv.Stop()
+	}()
 	log.Infoln("serving celestia-da over gRPC on:", lis.Addr())
 	err = srv.Serve(lis)
-	if !errors.Is(err, grpc.ErrServerStopped) {
+	if err != nil && !errors.Is(err, grpc.ErrServerStopped) {
 		log.Fatalln("gRPC server stopped with error:", err)
 	}
 }
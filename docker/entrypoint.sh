@@ -2,7 +2,7 @@
 
 set -e
 
-if [ "$1" = 'celestia-da' ]; then
+if [ "$1" = 'celestia-da' ] && [ "$2" != 'serve' ]; then
     echo "Initializing Celestia Node with command:"
 
     if [[ -n "$NODE_STORE" ]]; then
@@ -0,0 +1,53 @@
+// Package telemetry creates the metric instruments of the celestia and server packages.
+package telemetry
+
+import (
+	"log"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// Meter reports through the global meter provider, which celestia-node configures when started with --metrics.
+var Meter = otel.Meter("celestia-da")
+
+// Int64Counter creates a counter, falling back to a no-op if the meter rejects it.
+func Int64Counter(name string, opts ...metric.Int64CounterOption) metric.Int64Counter {
+	c, err := Meter.Int64Counter(name, opts...)
+	if err != nil {
+		log.Println("failed to create metric", name, err)
+		return noop.Int64Counter{}
+	}
+	return c
+}
+
+// Int64UpDownCounter creates an up-down counter, falling back to a no-op if the meter rejects it.
+func Int64UpDownCounter(name string, opts ...metric.Int64UpDownCounterOption) metric.Int64UpDownCounter {
+	c, err := Meter.Int64UpDownCounter(name, opts...)
+	if err != nil {
+		log.Println("failed to create metric", name, err)
+		return noop.Int64UpDownCounter{}
+	}
+	return c
+}
+
+// Float64Histogram creates a histogram, falling back to a no-op if the meter rejects it.
+func Float64Histogram(name string, opts ...metric.Float64HistogramOption) metric.Float64Histogram {
+	h, err := Meter.Float64Histogram(name, opts...)
+	if err != nil {
+		log.Println("failed to create metric", name, err)
+		return noop.Float64Histogram{}
+	}
+	return h
+}
+
+// Int64ObservableGauge creates an observable gauge, falling back to a no-op if the meter rejects it.
+func Int64ObservableGauge(name string, opts ...metric.Int64ObservableGaugeOption) metric.Int64ObservableGauge {
+	g, err := Meter.Int64ObservableGauge(name, opts...)
+	if err != nil {
+		log.Println("failed to create metric", name, err)
+		return noop.Int64ObservableGauge{}
+	}
+	return g
+}
@@ -0,0 +1,510 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+
+	logging "github.com/ipfs/go-log/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/rollkit/celestia-da/celestia"
+)
+
+var (
+	// ErrAdminUnauthenticated is returned by the admin service for requests without the admin token.
+	ErrAdminUnauthenticated = errors.New("missing or invalid admin token")
+	// ErrAdminTokenRequired is returned by the admin service if no admin token is configured.
+	ErrAdminTokenRequired = errors.New("admin operations require an admin token")
+	// ErrQueueDisabled is returned by queue inspection if submissions aren't queued.
+	ErrQueueDisabled = errors.New("submission queue is disabled")
+	// ErrInvalidLogLevel is returned for unknown log levels or logging subsystems.
+	ErrInvalidLogLevel = errors.New("invalid log level")
+	// ErrNamespaceImmutable is returned by SetNamespace, as the namespace of the service is fixed at
+	// startup: queued submissions, the submission index and the limits of clients refer to it.
+	ErrNamespaceImmutable = errors.New("the namespace of the service can't be changed at runtime, restart the service with the new namespace or pass it with each request")
+)
+
+// BalanceRequest requests the balance of the node account.
+type BalanceRequest struct{}
+
+// UsageRequest selects the client and namespace to report the usage of.
+type UsageRequest struct {
+	// Client is the identity of a client, no client usage is reported if empty.
+	Client string `json:"client,omitempty"`
+	// Namespace defaults to the namespace of the service.
+	Namespace []byte `json:"namespace,omitempty"`
+}
+
+// UsageResponse holds the usage of a client and a namespace today.
+type UsageResponse struct {
+	Client    *Usage `json:"client,omitempty"`
+	Namespace *Usage `json:"namespace"`
+}
+
+// ConfigRequest requests the configuration of the service.
+type ConfigRequest struct{}
+
+// ConfigResponse holds the configuration of the service.
+type ConfigResponse struct {
+	Service *celestia.Config `json:"service"`
+	// Limits is nil if rate limits and quotas are disabled.
+	Limits *LimitsConfig `json:"limits,omitempty"`
+	// Queue is nil if submissions aren't queued.
+	Queue *celestia.QueueConfig `json:"queue,omitempty"`
+}
+
+// SetGasPriceRequest changes the gas price of submissions without a gas price.
+type SetGasPriceRequest struct {
+	// GasPrice in utia/gas, negative for the default minimum gas price.
+	GasPrice float64 `json:"gas_price"`
+}
+
+// SetLimitsRequest replaces the rate limits and quotas.
+type SetLimitsRequest struct {
+	Limits LimitsConfig `json:"limits"`
+}
+
+// PauseRequest pauses submissions.
+type PauseRequest struct{}
+
+// ResumeRequest resumes paused submissions.
+type ResumeRequest struct{}
+
+// FlushCacheRequest drops the cache of retrieved blobs.
+type FlushCacheRequest struct{}
+
+// FlushCacheResponse holds the number of dropped cache entries.
+type FlushCacheResponse struct {
+	Entries int `json:"entries"`
+}
+
+// SetLogLevelRequest changes the log level of the logging subsystems of the node.
+type SetLogLevelRequest struct {
+	// Level is one of "debug", "info", "warn", "error", "dpanic", "panic" and "fatal".
+	Level string `json:"level"`
+	// Subsystem is the logging subsystem to change, all subsystems if empty.
+	Subsystem string `json:"subsystem,omitempty"`
+}
+
+// SetLogLevelResponse holds the changed log level.
+type SetLogLevelResponse struct {
+	Level     string `json:"level"`
+	Subsystem string `json:"subsystem"`
+}
+
+// SetNamespaceRequest changes the default namespace of the service.
+type SetNamespaceRequest struct {
+	Namespace []byte `json:"namespace"`
+}
+
+// QueueRequest requests the pending submissions of the queue.
+type QueueRequest struct{}
+
+// QueueResponse holds the pending submissions of the queue, in order of submission.
+type QueueResponse struct {
+	Paused  bool                        `json:"paused"`
+	Pending []celestia.SubmissionStatus `json:"pending"`
+}
+
+// AdminService reports the state of a celestia-da service to its operators, and changes it at
+// runtime.
+type AdminService interface {
+	Balance(context.Context, *BalanceRequest) (*celestia.Balance, error)
+	Usage(context.Context, *UsageRequest) (*UsageResponse, error)
+	Config(context.Context, *ConfigRequest) (*ConfigResponse, error)
+	Queue(context.Context, *QueueRequest) (*QueueResponse, error)
+	SetGasPrice(context.Context, *SetGasPriceRequest) (*ConfigResponse, error)
+	SetLimits(context.Context, *SetLimitsRequest) (*ConfigResponse, error)
+	Pause(context.Context, *PauseRequest) (*ConfigResponse, error)
+	Resume(context.Context, *ResumeRequest) (*ConfigResponse, error)
+	FlushCache(context.Context, *FlushCacheRequest) (*FlushCacheResponse, error)
+	SetLogLevel(context.Context, *SetLogLevelRequest) (*SetLogLevelResponse, error)
+	// SetNamespace always fails with ErrNamespaceImmutable.
+	SetNamespace(context.Context, *SetNamespaceRequest) (*ConfigResponse, error)
+}
+
+// AdminConfig holds the admin token and the optional components reported on by the admin service.
+type AdminConfig struct {
+	// Token authenticates requests, see AdminToken. If empty, every request fails with
+	// ErrAdminTokenRequired. The token is sent in the clear over connections without TLS, a
+	// warning is logged the first time it is received over one from a remote address.
+	Token string
+	// Limiter reports usage, Usage and SetLimits return ErrLimitsDisabled if nil.
+	Limiter *Limiter
+	// Queue reports pending submissions, Queue returns ErrQueueDisabled if nil.
+	Queue *celestia.Queue
+}
+
+// RegisterAdminService registers the admin service for c on srv.
+func RegisterAdminService(srv *grpc.Server, c *celestia.CelestiaDA, config AdminConfig) {
+	srv.RegisterService(&adminServiceDesc, &adminServer{da: c, config: config})
+}
+
+type adminServer struct {
+	da     *celestia.CelestiaDA
+	config AdminConfig
+	// insecure logs the warning about a token received over an insecure connection once.
+	insecure sync.Once
+}
+
+// adminTokenKey is the metadata key of the admin token.
+const adminTokenKey = "authorization"
+
+// authorize checks the admin token of a request.
+func (s *adminServer) authorize(ctx context.Context) error {
+	if s.config.Token == "" {
+		return statusError(ErrAdminTokenRequired)
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(adminTokenKey)
+	if len(values) > 0 {
+		s.checkTransport(ctx)
+	}
+	for _, value := range values {
+		token, ok := strings.CutPrefix(value, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.config.Token)) == 1 {
+			return nil
+		}
+	}
+	return statusError(ErrAdminUnauthenticated)
+}
+
+// checkTransport logs a warning the first time a token is received over a connection without
+// transport security from a remote address, as it could have been intercepted.
+func (s *adminServer) checkTransport(ctx context.Context) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || isLocal(p.Addr) {
+		return
+	}
+	type authInfo interface {
+		GetCommonAuthInfo() credentials.CommonAuthInfo
+	}
+	if info, ok := p.AuthInfo.(authInfo); ok && info.GetCommonAuthInfo().SecurityLevel == credentials.PrivacyAndIntegrity {
+		return
+	}
+	s.insecure.Do(func() {
+		log.Println("WARNING: admin token received over a connection without TLS, it may have been intercepted", "peer", p.Addr)
+	})
+}
+
+// isLocal returns true for unix sockets and loopback addresses.
+func isLocal(addr net.Addr) bool {
+	switch addr := addr.(type) {
+	case *net.UnixAddr:
+		return true
+	case *net.TCPAddr:
+		return addr.IP.IsLoopback()
+	}
+	return false
+}
+
+func (s *adminServer) Balance(ctx context.Context, _ *BalanceRequest) (*celestia.Balance, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	balance, err := s.da.Balance(ctx)
+	return balance, statusError(err)
+}
+
+func (s *adminServer) Usage(ctx context.Context, req *UsageRequest) (*UsageResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if s.config.Limiter == nil {
+		return nil, statusError(ErrLimitsDisabled)
+	}
+	client, namespace, err := s.config.Limiter.Usage(req.Client, req.Namespace)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &UsageResponse{Client: client, Namespace: namespace}, nil
+}
+
+func (s *adminServer) Config(ctx context.Context, _ *ConfigRequest) (*ConfigResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return s.configResponse(), nil
+}
+
+func (s *adminServer) configResponse() *ConfigResponse {
+	resp := &ConfigResponse{Service: s.da.Config()}
+	if s.config.Limiter != nil {
+		limits := s.config.Limiter.Limits()
+		resp.Limits = &limits
+	}
+	if s.config.Queue != nil {
+		queue := s.config.Queue.QueueConfig()
+		resp.Queue = &queue
+	}
+	return resp
+}
+
+func (s *adminServer) Queue(ctx context.Context, _ *QueueRequest) (*QueueResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if s.config.Queue == nil {
+		return nil, statusError(ErrQueueDisabled)
+	}
+	return &QueueResponse{Paused: s.da.Paused(), Pending: s.config.Queue.Pending()}, nil
+}
+
+func (s *adminServer) SetGasPrice(ctx context.Context, req *SetGasPriceRequest) (*ConfigResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	s.da.SetGasPrice(req.GasPrice)
+	return s.configResponse(), nil
+}
+
+func (s *adminServer) SetLimits(ctx context.Context, req *SetLimitsRequest) (*ConfigResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if s.config.Limiter == nil {
+		return nil, statusError(ErrLimitsDisabled)
+	}
+	if err := s.config.Limiter.SetLimits(req.Limits); err != nil {
+		return nil, statusError(err)
+	}
+	return s.configResponse(), nil
+}
+
+func (s *adminServer) Pause(ctx context.Context, _ *PauseRequest) (*ConfigResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	s.da.Pause()
+	return s.configResponse(), nil
+}
+
+func (s *adminServer) Resume(ctx context.Context, _ *ResumeRequest) (*ConfigResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	s.da.Resume()
+	return s.configResponse(), nil
+}
+
+func (s *adminServer) FlushCache(ctx context.Context, _ *FlushCacheRequest) (*FlushCacheResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	entries, err := s.da.FlushCache(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &FlushCacheResponse{Entries: entries}, nil
+}
+
+func (s *adminServer) SetLogLevel(ctx context.Context, req *SetLogLevelRequest) (*SetLogLevelResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	subsystem := req.Subsystem
+	if subsystem == "" {
+		subsystem = "*"
+	}
+	if err := logging.SetLogLevel(subsystem, req.Level); err != nil {
+		return nil, statusError(fmt.Errorf("%w %q of subsystem %q: %v", ErrInvalidLogLevel, req.Level, subsystem, err))
+	}
+	log.Println("changed log level", "subsystem", subsystem, "level", req.Level)
+	return &SetLogLevelResponse{Level: req.Level, Subsystem: subsystem}, nil
+}
+
+func (s *adminServer) SetNamespace(ctx context.Context, _ *SetNamespaceRequest) (*ConfigResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return nil, statusError(ErrNamespaceImmutable)
+}
+
+var adminServiceDesc = grpc.ServiceDesc{
+	ServiceName: "celestiada.v1.Admin",
+	HandlerType: (*AdminService)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("celestiada.v1.Admin", "Balance", func(srv any, ctx context.Context, req *BalanceRequest) (any, error) {
+			return srv.(AdminService).Balance(ctx, req)
+		}),
+		unaryMethod("celestiada.v1.Admin", "Usage", func(srv any, ctx context.Context, req *UsageRequest) (any, error) {
+			return srv.(AdminService).Usage(ctx, req)
+		}),
+		unaryMethod("celestiada.v1.Admin", "Config", func(srv any, ctx context.Context, req *ConfigRequest) (any, error) {
+			return srv.(AdminService).Config(ctx, req)
+		}),
+		unaryMethod("celestiada.v1.Admin", "Queue", func(srv any, ctx context.Context, req *QueueRequest) (any, error) {
+			return srv.(AdminService).Queue(ctx, req)
+		}),
+		unaryMethod("celestiada.v1.Admin", "SetGasPrice", func(srv any, ctx context.Context, req *SetGasPriceRequest) (any, error) {
+			return srv.(AdminService).SetGasPrice(ctx, req)
+		}),
+		unaryMethod("celestiada.v1.Admin", "SetLimits", func(srv any, ctx context.Context, req *SetLimitsRequest) (any, error) {
+			return srv.(AdminService).SetLimits(ctx, req)
+		}),
+		unaryMethod("celestiada.v1.Admin", "Pause", func(srv any, ctx context.Context, req *PauseRequest) (any, error) {
+			return srv.(AdminService).Pause(ctx, req)
+		}),
+		unaryMethod("celestiada.v1.Admin", "Resume", func(srv any, ctx context.Context, req *ResumeRequest) (any, error) {
+			return srv.(AdminService).Resume(ctx, req)
+		}),
+		unaryMethod("celestiada.v1.Admin", "SetLogLevel", func(srv any, ctx context.Context, req *SetLogLevelRequest) (any, error) {
+			return srv.(AdminService).SetLogLevel(ctx, req)
+		}),
+		unaryMethod("celestiada.v1.Admin", "SetNamespace", func(srv any, ctx context.Context, req *SetNamespaceRequest) (any, error) {
+			return srv.(AdminService).SetNamespace(ctx, req)
+		}),
+		unaryMethod("celestiada.v1.Admin", "FlushCache", func(srv any, ctx context.Context, req *FlushCacheRequest) (any, error) {
+			return srv.(AdminService).FlushCache(ctx, req)
+		}),
+	},
+}
+
+// adminToken sends the admin token with every call.
+type adminToken string
+
+func (t adminToken) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{adminTokenKey: "Bearer " + string(t)}, nil
+}
+
+func (adminToken) RequireTransportSecurity() bool {
+	return false
+}
+
+// AdminToken returns a call option authenticating calls to the admin service with token.
+func AdminToken(token string) grpc.CallOption {
+	return grpc.PerRPCCredentials(adminToken(token))
+}
+
+// AdminClient queries and operates the admin service of a celestia-da service.
+type AdminClient struct {
+	cc   grpc.ClientConnInterface
+	opts []grpc.CallOption
+}
+
+// NewAdminClient returns a client for the admin service served on cc, which applies opts, such as
+// AdminToken, to every call.
+func NewAdminClient(cc grpc.ClientConnInterface, opts ...grpc.CallOption) *AdminClient {
+	return &AdminClient{cc: cc, opts: opts}
+}
+
+// invoke calls a method of the admin service.
+func (c *AdminClient) invoke(ctx context.Context, method string, req, out any, opts []grpc.CallOption) error {
+	method = "/celestiada.v1.Admin/" + method
+	opts = append(append([]grpc.CallOption{}, c.opts...), opts...)
+	if err := c.cc.Invoke(ctx, method, req, out, callOptions(opts)...); err != nil {
+		return clientError(err)
+	}
+	return nil
+}
+
+// Balance returns the balance of the node account.
+func (c *AdminClient) Balance(ctx context.Context, opts ...grpc.CallOption) (*celestia.Balance, error) {
+	out := new(celestia.Balance)
+	if err := c.invoke(ctx, "Balance", &BalanceRequest{}, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Usage returns the usage today of the requested client and namespace.
+func (c *AdminClient) Usage(ctx context.Context, req UsageRequest, opts ...grpc.CallOption) (*UsageResponse, error) {
+	out := new(UsageResponse)
+	if err := c.invoke(ctx, "Usage", &req, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Config returns the configuration of the service.
+func (c *AdminClient) Config(ctx context.Context, opts ...grpc.CallOption) (*ConfigResponse, error) {
+	out := new(ConfigResponse)
+	if err := c.invoke(ctx, "Config", &ConfigRequest{}, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Queue returns the pending submissions of the queue.
+func (c *AdminClient) Queue(ctx context.Context, opts ...grpc.CallOption) (*QueueResponse, error) {
+	out := new(QueueResponse)
+	if err := c.invoke(ctx, "Queue", &QueueRequest{}, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SetGasPrice changes the gas price of submissions without a gas price, and returns the new
+// configuration.
+func (c *AdminClient) SetGasPrice(ctx context.Context, gasPrice float64, opts ...grpc.CallOption) (*ConfigResponse, error) {
+	out := new(ConfigResponse)
+	if err := c.invoke(ctx, "SetGasPrice", &SetGasPriceRequest{GasPrice: gasPrice}, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SetLimits replaces the rate limits and quotas, and returns the new configuration.
+func (c *AdminClient) SetLimits(ctx context.Context, limits LimitsConfig, opts ...grpc.CallOption) (*ConfigResponse, error) {
+	out := new(ConfigResponse)
+	if err := c.invoke(ctx, "SetLimits", &SetLimitsRequest{Limits: limits}, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Pause pauses submissions, and returns the new configuration.
+func (c *AdminClient) Pause(ctx context.Context, opts ...grpc.CallOption) (*ConfigResponse, error) {
+	out := new(ConfigResponse)
+	if err := c.invoke(ctx, "Pause", &PauseRequest{}, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Resume resumes paused submissions, and returns the new configuration.
+func (c *AdminClient) Resume(ctx context.Context, opts ...grpc.CallOption) (*ConfigResponse, error) {
+	out := new(ConfigResponse)
+	if err := c.invoke(ctx, "Resume", &ResumeRequest{}, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FlushCache drops the cache of retrieved blobs, and returns the number of dropped entries.
+func (c *AdminClient) FlushCache(ctx context.Context, opts ...grpc.CallOption) (*FlushCacheResponse, error) {
+	out := new(FlushCacheResponse)
+	if err := c.invoke(ctx, "FlushCache", &FlushCacheRequest{}, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SetLogLevel changes the log level of a logging subsystem of the node, or of all subsystems if
+// subsystem is empty.
+func (c *AdminClient) SetLogLevel(ctx context.Context, level, subsystem string, opts ...grpc.CallOption) (*SetLogLevelResponse, error) {
+	out := new(SetLogLevelResponse)
+	if err := c.invoke(ctx, "SetLogLevel", &SetLogLevelRequest{Level: level, Subsystem: subsystem}, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SetNamespace changes the default namespace of the service, which always fails with
+// ErrNamespaceImmutable.
+func (c *AdminClient) SetNamespace(ctx context.Context, ns []byte, opts ...grpc.CallOption) (*ConfigResponse, error) {
+	out := new(ConfigResponse)
+	if err := c.invoke(ctx, "SetNamespace", &SetNamespaceRequest{Namespace: ns}, out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	rpc "github.com/celestiaorg/celestia-node/api/rpc/client"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/rollkit/celestia-da/celestia"
+	proxygrpc "github.com/rollkit/go-da/proxy/grpc"
+)
+
+func TestAdminService(t *testing.T) {
+	ctx := context.Background()
+	mock := celestia.NewMockService()
+	defer mock.Close()
+
+	ns, err := share.NewBlobNamespaceV0([]byte("admin"))
+	require.NoError(t, err)
+	client, err := rpc.NewClient(ctx, mock.URL(), "")
+	require.NoError(t, err)
+	defer client.Close()
+	config := celestia.BalanceConfig{Interval: 10 * time.Millisecond, Refuse: true}
+	c, err := celestia.NewCelestiaDAWithOptions(client, ns, -1, ctx, celestia.WithBalanceMonitor(config))
+	require.NoError(t, err)
+	defer c.Close()
+
+	srv := proxygrpc.NewServer(c, grpc.UnaryInterceptor(UnaryServerInterceptor))
+	RegisterAdminService(srv, c, AdminConfig{Token: "secret"})
+	addr := listen(t, srv)
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	admin := NewAdminClient(conn, AdminToken("secret"))
+
+	balance, err := admin.Balance(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "utia", balance.Denom)
+	assert.NotZero(t, balance.Amount)
+	assert.False(t, balance.Low)
+
+	// the log level changes at runtime
+	level, err := admin.SetLogLevel(ctx, "error", "")
+	require.NoError(t, err)
+	assert.Equal(t, "*", level.Subsystem)
+	_, err = admin.SetLogLevel(ctx, "verbose", "")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.ErrorIs(t, err, ErrInvalidLogLevel)
+	_, err = admin.SetLogLevel(ctx, "error", "no-such-subsystem")
+	assert.ErrorIs(t, err, ErrInvalidLogLevel)
+
+	// the namespace doesn't
+	_, err = admin.SetNamespace(ctx, []byte("other"))
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+	assert.ErrorIs(t, err, ErrNamespaceImmutable)
+	_, err = NewAdminClient(conn).SetNamespace(ctx, []byte("other"))
+	assert.ErrorIs(t, err, ErrAdminUnauthenticated)
+
+	// submissions with fees exceeding the balance are refused
+	daClient := proxygrpc.NewClient()
+	require.NoError(t, daClient.Start(addr, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithUnaryInterceptor(UnaryClientInterceptor)))
+	defer daClient.Stop()
+	// until the monitor queried the balance, the node rejects the transaction instead
+	assert.Eventually(t, func() bool {
+		_, err = daClient.Submit(ctx, [][]byte{[]byte("blob")}, float64(balance.Amount), nil)
+		return status.Code(err) == codes.ResourceExhausted
+	}, time.Second, 10*time.Millisecond)
+	assert.ErrorIs(t, err, celestia.ErrInsufficientFunds)
+}
+
+func TestAdminService_Operations(t *testing.T) {
+	ctx := context.Background()
+	mock := celestia.NewMockService()
+	defer mock.Close()
+
+	ns, err := share.NewBlobNamespaceV0([]byte("admin"))
+	require.NoError(t, err)
+	client, err := rpc.NewClient(ctx, mock.URL(), "")
+	require.NoError(t, err)
+	defer client.Close()
+	c, err := celestia.NewCelestiaDAWithOptions(client, ns, -1, ctx, celestia.WithCache(celestia.DefaultCacheConfig()))
+	require.NoError(t, err)
+	defer c.Close()
+	queueConfig := celestia.DefaultQueueConfig(t.TempDir())
+	queue, err := celestia.NewQueue(c, queueConfig)
+	require.NoError(t, err)
+	defer queue.Close()
+	limiter, err := NewLimiter(c, LimitsConfig{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	srv := proxygrpc.NewServer(queue, grpc.UnaryInterceptor(UnaryServerInterceptor))
+	RegisterReceiptsService(srv, c)
+	RegisterAdminService(srv, c, AdminConfig{Token: "secret", Limiter: limiter, Queue: queue})
+	conn := dial(t, srv)
+	admin := NewAdminClient(conn, AdminToken("secret"))
+
+	// every method requires the token once one is configured
+	_, err = NewAdminClient(conn).Config(ctx)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.ErrorIs(t, err, ErrAdminUnauthenticated)
+	_, err = NewAdminClient(conn, AdminToken("wrong")).Pause(ctx)
+	assert.ErrorIs(t, err, ErrAdminUnauthenticated)
+	assert.False(t, c.Paused())
+
+	config, err := admin.Config(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(ns), []byte(config.Service.Namespace))
+	assert.Equal(t, float64(-1), config.Service.GasPrice)
+	require.NotNil(t, config.Service.Cache)
+	assert.Equal(t, celestia.DefaultCacheConfig().MaxBytes, config.Service.Cache.MaxBytes)
+	require.NotNil(t, config.Queue)
+	assert.Equal(t, queueConfig.Dir, config.Queue.Dir)
+	require.NotNil(t, config.Limits)
+
+	config, err = admin.SetGasPrice(ctx, 0.01)
+	require.NoError(t, err)
+	assert.Equal(t, 0.01, config.Service.GasPrice)
+	assert.Equal(t, 0.01, c.GasPrice())
+
+	config, err = admin.SetLimits(ctx, LimitsConfig{Client: Limit{DailyBytes: 1000}})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1000), config.Limits.Client.DailyBytes)
+	_, err = admin.SetLimits(ctx, LimitsConfig{Namespaces: map[string]Limit{"": {}}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	config, err = admin.Pause(ctx)
+	require.NoError(t, err)
+	assert.True(t, config.Service.Paused)
+	_, err = NewReceiptsClient(conn).Submit(ctx, SubmitRequest{Blobs: [][]byte{[]byte("blob")}, GasPrice: -1})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.ErrorIs(t, err, celestia.ErrPaused)
+
+	// queued submissions are held while paused
+	daClient := proxygrpc.NewClient()
+	require.NoError(t, daClient.Start(listen(t, srv), grpc.WithTransportCredentials(insecure.NewCredentials())))
+	defer daClient.Stop()
+	ids, err := daClient.Submit(ctx, [][]byte{[]byte("queued")}, -1, nil)
+	require.NoError(t, err)
+	ticket, _, ok := celestia.TicketFromID(ids[0])
+	require.True(t, ok)
+	pending, err := admin.Queue(ctx)
+	require.NoError(t, err)
+	assert.True(t, pending.Paused)
+	require.Len(t, pending.Pending, 1)
+	assert.Equal(t, ticket, pending.Pending[0].Ticket)
+
+	config, err = admin.Resume(ctx)
+	require.NoError(t, err)
+	assert.False(t, config.Service.Paused)
+	assert.Eventually(t, func() bool {
+		pending, err := admin.Queue(ctx)
+		return err == nil && len(pending.Pending) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	// retrieved blobs are cached until flushed
+	st, err := queue.Status(ticket)
+	require.NoError(t, err)
+	_, err = daClient.Get(ctx, st.IDs, nil)
+	require.NoError(t, err)
+	flushed, err := admin.FlushCache(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, flushed.Entries)
+
+	// without a token, the admin service is disabled
+	plain := grpc.NewServer()
+	RegisterAdminService(plain, c, AdminConfig{})
+	unauthenticated := NewAdminClient(dial(t, plain))
+	_, err = unauthenticated.Config(ctx)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.ErrorIs(t, err, ErrAdminTokenRequired)
+	_, err = unauthenticated.Pause(ctx)
+	assert.ErrorIs(t, err, ErrAdminTokenRequired)
+
+	noQueue := grpc.NewServer()
+	RegisterAdminService(noQueue, c, AdminConfig{Token: "secret"})
+	_, err = NewAdminClient(dial(t, noQueue), AdminToken("secret")).Queue(ctx)
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+	assert.ErrorIs(t, err, ErrQueueDisabled)
+}
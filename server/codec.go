@@ -0,0 +1,63 @@
+// Package server implements the gRPC services celestia-da serves next to the DA interface, and
+// clients for them.
+//
+// The services use JSON encoded messages, selected with the "json" content subtype, so they don't
+// require generated protobuf code. Clients created by this package set the content subtype on every
+// call.
+package server
+
+import (
+	"context"
+	"encoding/json"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+)
+
+// codecName is the content subtype of the celestia-da extension services.
+const codecName = "json"
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// jsonCodec encodes gRPC messages as JSON.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return codecName
+}
+
+// callOptions returns the options for calls to the extension services.
+func callOptions(opts []grpc.CallOption) []grpc.CallOption {
+	return append([]grpc.CallOption{grpc.CallContentSubtype(codecName)}, opts...)
+}
+
+// unaryMethod returns the description of a unary method calling handler with the decoded request.
+func unaryMethod[Req any](service, method string, handler func(srv any, ctx context.Context, req *Req) (any, error)) grpc.MethodDesc {
+	fullMethod := "/" + service + "/" + method
+	return grpc.MethodDesc{
+		MethodName: method,
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			req := new(Req)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return handler(srv, ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod}
+			return interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+				return handler(srv, ctx, req.(*Req))
+			})
+		},
+	}
+}
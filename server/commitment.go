@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	"github.com/rollkit/celestia-da/celestia"
+)
+
+// CommitmentRequest selects a blob by commitment.
+type CommitmentRequest struct {
+	// Namespace defaults to the namespace of the service.
+	Namespace  []byte `json:"namespace,omitempty"`
+	Commitment []byte `json:"commitment"`
+	// ScanDepth is the number of heights below the head to scan for blobs missing from the
+	// submission index, 0 scans the default of 100.
+	ScanDepth uint64 `json:"scan_depth,omitempty"`
+}
+
+// CommitmentService returns blobs by commitment, without knowing their height.
+type CommitmentService interface {
+	Get(context.Context, *CommitmentRequest) (*celestia.CommitmentResult, error)
+}
+
+// RegisterCommitmentService registers the commitment service for c on srv.
+func RegisterCommitmentService(srv *grpc.Server, c *celestia.CelestiaDA) {
+	srv.RegisterService(&commitmentServiceDesc, &commitmentServer{da: c})
+}
+
+type commitmentServer struct {
+	da *celestia.CelestiaDA
+}
+
+func (s *commitmentServer) Get(ctx context.Context, req *CommitmentRequest) (*celestia.CommitmentResult, error) {
+	result, err := s.da.GetByCommitment(ctx, req.Commitment, req.Namespace, req.ScanDepth)
+	return result, statusError(err)
+}
+
+var commitmentServiceDesc = grpc.ServiceDesc{
+	ServiceName: "celestiada.v1.Commitment",
+	HandlerType: (*CommitmentService)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("celestiada.v1.Commitment", "Get", func(srv any, ctx context.Context, req *CommitmentRequest) (any, error) {
+			return srv.(CommitmentService).Get(ctx, req)
+		}),
+	},
+}
+
+// CommitmentClient returns blobs by commitment.
+type CommitmentClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewCommitmentClient returns a client for the commitment service served on cc.
+func NewCommitmentClient(cc grpc.ClientConnInterface) *CommitmentClient {
+	return &CommitmentClient{cc: cc}
+}
+
+// Get returns the ID, blob and inclusion proof of the blob with the requested commitment.
+func (c *CommitmentClient) Get(ctx context.Context, req CommitmentRequest, opts ...grpc.CallOption) (*celestia.CommitmentResult, error) {
+	out := new(celestia.CommitmentResult)
+	if err := c.cc.Invoke(ctx, "/celestiada.v1.Commitment/Get", &req, out, callOptions(opts)...); err != nil {
+		return nil, clientError(err)
+	}
+	return out, nil
+}
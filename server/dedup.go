@@ -0,0 +1,304 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/rollkit/celestia-da/celestia"
+)
+
+// SubmitKeyMetadata is the gRPC metadata key of the idempotency key of a submission, see
+// WithSubmitKey.
+const SubmitKeyMetadata = "idempotency-key"
+
+// maxSubmitKeyLength bounds the length of idempotency keys.
+const maxSubmitKeyLength = 256
+
+var (
+	// ErrSubmitKeyReused is returned for submissions whose idempotency key was used by a recent
+	// submission of different blobs.
+	ErrSubmitKeyReused = errors.New("idempotency key was used for different blobs")
+	// ErrInvalidSubmitKey is returned for submissions with an idempotency key that is too long.
+	ErrInvalidSubmitKey = errors.New("invalid idempotency key")
+)
+
+// WithSubmitKey returns a context sending key as the idempotency key of the submissions made with
+// it. Submissions with the same key as a recent submission return the result of the first one
+// instead of submitting the blobs again, see Deduplicator.
+func WithSubmitKey(ctx context.Context, key string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, SubmitKeyMetadata, key)
+}
+
+// DedupConfig configures the deduplication of submissions.
+//
+// Submissions are remembered in memory only, so a submission retried after a restart of the
+// server is submitted again.
+type DedupConfig struct {
+	// TTL is how long the result of a submission is remembered after it completed.
+	TTL time.Duration
+	// MaxEntries bounds the number of remembered submissions, dropping the oldest first.
+	MaxEntries int
+	// Timeout bounds submissions, which continue if the request that started them is canceled.
+	Timeout time.Duration
+	// Derive derives the key of submissions without an idempotency key from their namespace and
+	// blob commitments, so identical blobs submitted within TTL are only submitted once.
+	Derive bool
+	// Identity returns the identity of the client of a request, which scopes its keys so clients
+	// can't collide with, or see the results of, the submissions of other clients. It defaults to
+	// the identity used by the limiter, see LimitsConfig.
+	Identity func(context.Context) string
+}
+
+// DefaultDedupConfig returns the default deduplication configuration.
+func DefaultDedupConfig() DedupConfig {
+	return DedupConfig{
+		TTL:        10 * time.Minute,
+		MaxEntries: 100000,
+		Timeout:    10 * time.Minute,
+	}
+}
+
+// dedupEntry is a remembered submission.
+type dedupEntry struct {
+	// fingerprint is the hash of the namespace and blobs of the submission.
+	fingerprint [sha256.Size]byte
+	// done is closed once the submission completed.
+	done chan struct{}
+	resp any
+	err  error
+	// progress is the progress of a submission that failed after blobs were, or may have been,
+	// included, which the next submission with the same key resumes.
+	progress *celestia.SubmitProgress
+	// header and trailer hold the metadata set by the submission, sent to every request.
+	header  metadata.MD
+	trailer metadata.MD
+	expires time.Time
+}
+
+// Deduplicator makes the submissions of a gRPC server idempotent, see UnaryServerInterceptor.
+type Deduplicator struct {
+	da     *celestia.CelestiaDA
+	config DedupConfig
+
+	mu      sync.Mutex
+	entries map[string]*dedupEntry
+	// order holds the entries in the order they were added.
+	order []dedupKey
+}
+
+// dedupKey is an entry and its key.
+type dedupKey struct {
+	key   string
+	entry *dedupEntry
+}
+
+// NewDeduplicator returns a deduplicator for the submissions to c.
+func NewDeduplicator(c *celestia.CelestiaDA, config DedupConfig) *Deduplicator {
+	if config.Identity == nil {
+		config.Identity = callerHost
+	}
+	return &Deduplicator{
+		da:      c,
+		config:  config,
+		entries: make(map[string]*dedupEntry),
+	}
+}
+
+// UnaryServerInterceptor deduplicates submissions by their idempotency key, given by the
+// SubmitKeyMetadata metadata or derived with DedupConfig.Derive, per client. The first submission with a key
+// is submitted, detached from the request so that it completes if the client gives up, and
+// submissions with the same key wait for it and return its result, unless it failed. A submission
+// that failed with a celestia.SubmitError is resumed by the next submission with the same key, see
+// celestia.ContextWithProgress, so the blobs that were included aren't submitted again. Submissions
+// with a key that was used for different blobs fail with ErrSubmitKeyReused.
+//
+// The interceptor must follow CallerInterceptor, which records the client, and precede the limiter,
+// so duplicates aren't charged twice.
+func (d *Deduplicator) UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	blobs, _, ns, ok := submission(req)
+	if !ok {
+		return handler(ctx, req)
+	}
+	if len(ns) == 0 {
+		ns = d.da.Namespace()
+	}
+	key, source, err := d.key(ctx, blobs, ns)
+	if err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return handler(ctx, req)
+	}
+	key = d.config.Identity(ctx) + "/" + info.FullMethod + "/" + key
+	fingerprint := dedupFingerprint(blobs, ns)
+
+	d.mu.Lock()
+	d.prune(time.Now())
+	e, duplicate := d.entries[key]
+	if duplicate && e.fingerprint != fingerprint {
+		d.mu.Unlock()
+		return nil, ErrSubmitKeyReused
+	}
+	submitCtx := ctx
+	if duplicate && e.progress != nil {
+		// the failed submission is resumed rather than submitted again from the start
+		submitCtx = celestia.ContextWithProgress(ctx, *e.progress)
+		duplicate = false
+	}
+	if !duplicate {
+		e = &dedupEntry{fingerprint: fingerprint, done: make(chan struct{})}
+		d.entries[key] = e
+		d.order = append(d.order, dedupKey{key, e})
+		go d.submit(submitCtx, key, e, req, info, handler)
+	}
+	d.mu.Unlock()
+	if duplicate {
+		daMetrics.duplicates.Add(ctx, 1, metric.WithAttributes(attribute.String("key", source)))
+	}
+
+	select {
+	case <-e.done:
+		if e.header.Len() > 0 {
+			_ = grpc.SetHeader(ctx, e.header)
+		}
+		if e.trailer.Len() > 0 {
+			_ = grpc.SetTrailer(ctx, e.trailer)
+		}
+		return e.resp, e.err
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// submit handles the submission of e detached from the request, and forgets it if it failed before
+// any blobs were, or may have been, included. Otherwise its progress is kept for the next
+// submission with the same key.
+func (d *Deduplicator) submit(ctx context.Context, key string, e *dedupEntry, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), d.config.Timeout)
+	defer cancel()
+	stream := &recordingStream{method: info.FullMethod}
+	resp, err := handler(grpc.NewContextWithServerTransportStream(ctx, stream), req)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e.resp, e.err = resp, err
+	e.header, e.trailer = stream.metadata()
+	e.expires = time.Now().Add(d.config.TTL)
+	var submitErr *celestia.SubmitError
+	if errors.As(err, &submitErr) {
+		e.progress = &submitErr.Progress
+	} else if err != nil {
+		// the blobs weren't included, or the error would hide their IDs, so a retry submits them again
+		delete(d.entries, key)
+	}
+	close(e.done)
+}
+
+// recordingStream records the metadata set by a submission handled detached from its request.
+type recordingStream struct {
+	method string
+
+	mu      sync.Mutex
+	header  metadata.MD
+	trailer metadata.MD
+}
+
+func (s *recordingStream) Method() string {
+	return s.method
+}
+
+func (s *recordingStream) SetHeader(md metadata.MD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *recordingStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *recordingStream) SetTrailer(md metadata.MD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
+
+// metadata returns the recorded header and trailer.
+func (s *recordingStream) metadata() (metadata.MD, metadata.MD) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.header, s.trailer
+}
+
+// key returns the idempotency key of a submission and where it came from, empty if it has none.
+func (d *Deduplicator) key(ctx context.Context, blobs [][]byte, ns []byte) (string, string, error) {
+	if keys := metadata.ValueFromIncomingContext(ctx, SubmitKeyMetadata); len(keys) > 0 && keys[0] != "" {
+		if len(keys[0]) > maxSubmitKeyLength {
+			return "", "", fmt.Errorf("%w: longer than %d characters", ErrInvalidSubmitKey, maxSubmitKeyLength)
+		}
+		return "client:" + keys[0], "client", nil
+	}
+	if !d.config.Derive {
+		return "", "", nil
+	}
+	commitments, err := d.da.Commit(ctx, blobs, ns)
+	if err != nil {
+		// the commitments of chunked blobs depend on their heights, they are submitted without key
+		return "", "", nil
+	}
+	h := sha256.New()
+	for _, c := range commitments {
+		h.Write(c)
+	}
+	return "derived:" + hex.EncodeToString(ns) + "/" + hex.EncodeToString(h.Sum(nil)), "derived", nil
+}
+
+// prune drops the entries that expired, and the oldest completed entries beyond the max entries.
+// Must be called with d.mu held.
+func (d *Deduplicator) prune(now time.Time) {
+	for len(d.order) > 0 {
+		oldest := d.order[0]
+		// entries of failed submissions were dropped already
+		if d.entries[oldest.key] == oldest.entry {
+			select {
+			case <-oldest.entry.done:
+			default:
+				// in flight entries are kept
+				return
+			}
+			if now.Before(oldest.entry.expires) && len(d.entries) < d.config.MaxEntries {
+				return
+			}
+			delete(d.entries, oldest.key)
+		}
+		d.order = d.order[1:]
+	}
+}
+
+// dedupFingerprint returns the hash of the namespace and blobs of a submission.
+func dedupFingerprint(blobs [][]byte, ns []byte) [sha256.Size]byte {
+	h := sha256.New()
+	var length [8]byte
+	for _, b := range append([][]byte{ns}, blobs...) {
+		binary.BigEndian.PutUint64(length[:], uint64(len(b)))
+		h.Write(length[:])
+		h.Write(b)
+	}
+	var fingerprint [sha256.Size]byte
+	copy(fingerprint[:], h.Sum(nil))
+	return fingerprint
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rpc "github.com/celestiaorg/celestia-node/api/rpc/client"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/rollkit/celestia-da/celestia"
+	proxygrpc "github.com/rollkit/go-da/proxy/grpc"
+	pbda "github.com/rollkit/go-da/types/pb/da"
+)
+
+func TestDeduplicator(t *testing.T) {
+	ctx := context.Background()
+	mock := celestia.NewMockService()
+	defer mock.Close()
+
+	ns, err := share.NewBlobNamespaceV0([]byte("dedup"))
+	require.NoError(t, err)
+	client, err := rpc.NewClient(ctx, mock.URL(), "")
+	require.NoError(t, err)
+	defer client.Close()
+	c := celestia.NewCelestiaDA(client, ns, -1, ctx)
+	// height returns the number of submitted transactions, the mock includes each at a new height
+	height := func() uint64 {
+		head, err := client.Header.LocalHead(ctx)
+		require.NoError(t, err)
+		return head.Height()
+	}
+
+	// start serves c with a deduplicator for config, and returns a client for the DA service
+	start := func(t *testing.T, config DedupConfig) *proxygrpc.Client {
+		dedup := NewDeduplicator(c, config)
+		srv := proxygrpc.NewServer(c, grpc.ChainUnaryInterceptor(UnaryServerInterceptor, dedup.UnaryServerInterceptor))
+		daClient := proxygrpc.NewClient()
+		require.NoError(t, daClient.Start(listen(t, srv),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithUnaryInterceptor(UnaryClientInterceptor)))
+		t.Cleanup(func() { _ = daClient.Stop() })
+		return daClient
+	}
+
+	t.Run("Key", func(t *testing.T) {
+		daClient := start(t, DefaultDedupConfig())
+		keyCtx := WithSubmitKey(ctx, "first")
+		ids, err := daClient.Submit(keyCtx, [][]byte{[]byte("blob")}, -1, nil)
+		require.NoError(t, err)
+		submitted := height()
+		duplicate, err := daClient.Submit(keyCtx, [][]byte{[]byte("blob")}, -1, nil)
+		require.NoError(t, err)
+		assert.Equal(t, ids, duplicate)
+		assert.Equal(t, submitted, height())
+
+		_, err = daClient.Submit(keyCtx, [][]byte{[]byte("other")}, -1, nil)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.ErrorContains(t, err, ErrSubmitKeyReused.Error())
+
+		// submissions without key aren't deduplicated
+		_, err = daClient.Submit(ctx, [][]byte{[]byte("blob")}, -1, nil)
+		require.NoError(t, err)
+		assert.Equal(t, submitted+1, height())
+	})
+
+	t.Run("Derive", func(t *testing.T) {
+		config := DefaultDedupConfig()
+		config.Derive = true
+		daClient := start(t, config)
+		ids, err := daClient.Submit(ctx, [][]byte{[]byte("derived")}, -1, nil)
+		require.NoError(t, err)
+		submitted := height()
+		duplicate, err := daClient.Submit(ctx, [][]byte{[]byte("derived")}, -1, nil)
+		require.NoError(t, err)
+		assert.Equal(t, ids, duplicate)
+		assert.Equal(t, submitted, height())
+
+		_, err = daClient.Submit(ctx, [][]byte{[]byte("different")}, -1, nil)
+		require.NoError(t, err)
+		assert.Equal(t, submitted+1, height())
+	})
+
+	t.Run("Detached", func(t *testing.T) {
+		config := DefaultDedupConfig()
+		config.TTL = time.Hour
+		dedup := NewDeduplicator(c, config)
+		info := &grpc.UnaryServerInfo{FullMethod: "/da.DAService/Submit"}
+		req := &pbda.SubmitRequest{Blobs: []*pbda.Blob{{Value: []byte("blob")}}}
+		keyCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(SubmitKeyMetadata, "detached"))
+
+		var calls atomic.Int64
+		release := make(chan error)
+		handler := func(context.Context, any) (any, error) {
+			calls.Add(1)
+			if err := <-release; err != nil {
+				return nil, err
+			}
+			return &pbda.SubmitResponse{}, nil
+		}
+
+		// the submission completes after the client gave up, and its failure is forgotten
+		canceled, cancel := context.WithCancel(keyCtx)
+		cancel()
+		_, err := dedup.UnaryServerInterceptor(canceled, req, info, handler)
+		assert.Equal(t, codes.Canceled, status.Code(err))
+		failed := errors.New("failed")
+		release <- failed
+		_, err = dedup.UnaryServerInterceptor(keyCtx, req, info, func(context.Context, any) (any, error) {
+			return nil, failed
+		})
+		assert.ErrorIs(t, err, failed)
+		assert.Equal(t, int64(1), calls.Load())
+
+		// concurrent duplicates wait for the first submission
+		results := make(chan any)
+		for i := 0; i < 2; i++ {
+			go func() {
+				resp, err := dedup.UnaryServerInterceptor(keyCtx, req, info, handler)
+				assert.NoError(t, err)
+				results <- resp
+			}()
+		}
+		release <- nil
+		first, second := <-results, <-results
+		assert.Same(t, first, second)
+		assert.Equal(t, int64(2), calls.Load())
+
+		// keys are scoped by client
+		other := &pbda.SubmitRequest{Blobs: []*pbda.Blob{{Value: []byte("other")}}}
+		otherCtx := celestia.ContextWithCaller(keyCtx, "10.0.0.2:4000")
+		_, err = dedup.UnaryServerInterceptor(otherCtx, other, info, func(context.Context, any) (any, error) {
+			return &pbda.SubmitResponse{}, nil
+		})
+		assert.NoError(t, err)
+		_, err = dedup.UnaryServerInterceptor(keyCtx, other, info, handler)
+		assert.ErrorIs(t, err, ErrSubmitKeyReused)
+
+		long := metadata.NewIncomingContext(ctx, metadata.Pairs(SubmitKeyMetadata, strings.Repeat("k", maxSubmitKeyLength+1)))
+		_, err = dedup.UnaryServerInterceptor(long, req, info, handler)
+		assert.ErrorIs(t, err, ErrInvalidSubmitKey)
+	})
+	t.Run("Partial", func(t *testing.T) {
+		dedup := NewDeduplicator(c, DefaultDedupConfig())
+		info := &grpc.UnaryServerInfo{FullMethod: "/da.DAService/Submit"}
+		blobs := [][]byte{[]byte("included"), []byte("remaining")}
+		req := &pbda.SubmitRequest{Blobs: []*pbda.Blob{{Value: blobs[0]}, {Value: blobs[1]}}}
+		keyCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(SubmitKeyMetadata, "partial"))
+		submit := func(ctx context.Context, _ any) (any, error) {
+			ids, err := c.Submit(ctx, blobs, -1, nil)
+			if err != nil {
+				return nil, err
+			}
+			return &pbda.SubmitResponse{Ids: []*pbda.ID{{Value: ids[0]}, {Value: ids[1]}}}, nil
+		}
+
+		// the first blob is included before the submission fails
+		included, err := c.Submit(ctx, blobs[:1], -1, nil)
+		require.NoError(t, err)
+		failed := &celestia.SubmitError{Progress: celestia.SubmitProgress{IDs: included}, Blobs: 2, Err: errors.New("failed")}
+		_, err = dedup.UnaryServerInterceptor(keyCtx, req, info, func(context.Context, any) (any, error) {
+			return nil, failed
+		})
+		assert.ErrorIs(t, err, failed)
+
+		// the retry resumes the submission, and only submits the remaining blob
+		submitted := height()
+		resp, err := dedup.UnaryServerInterceptor(keyCtx, req, info, submit)
+		require.NoError(t, err)
+		ids := resp.(*pbda.SubmitResponse).GetIds()
+		require.Len(t, ids, 2)
+		assert.Equal(t, included[0], ids[0].GetValue())
+		assert.Equal(t, submitted+1, height())
+
+		// later duplicates return the result of the resumed submission
+		duplicate, err := dedup.UnaryServerInterceptor(keyCtx, req, info, submit)
+		require.NoError(t, err)
+		assert.Same(t, resp, duplicate)
+		assert.Equal(t, submitted+1, height())
+	})
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/rollkit/celestia-da/celestia"
+)
+
+// errorDomain is the domain of the error details attached to status errors, see errorCodes.
+const errorDomain = "celestia-da"
+
+// errorCodes maps the errors of the celestia package and of this package to gRPC status codes, and
+// to the reason of the ErrorInfo detail attached to the status, which identifies the error on the
+// client. Reasons are part of the wire protocol and must not change.
+var errorCodes = []struct {
+	err    error
+	code   codes.Code
+	reason string
+}{
+	{celestia.ErrUnknownTicket, codes.NotFound, "UNKNOWN_TICKET"},
+	{celestia.ErrIndexDisabled, codes.Unimplemented, "INDEX_DISABLED"},
+	{ErrLimitsDisabled, codes.Unimplemented, "LIMITS_DISABLED"},
+	{ErrQueueDisabled, codes.Unimplemented, "QUEUE_DISABLED"},
+	{ErrNamespaceImmutable, codes.Unimplemented, "NAMESPACE_IMMUTABLE"},
+	{celestia.ErrCacheDisabled, codes.Unimplemented, "CACHE_DISABLED"},
+	{ErrAdminUnauthenticated, codes.Unauthenticated, "ADMIN_UNAUTHENTICATED"},
+	{ErrAdminTokenRequired, codes.PermissionDenied, "ADMIN_TOKEN_REQUIRED"},
+	{celestia.ErrPaused, codes.Unavailable, "PAUSED"},
+	{celestia.ErrBlobNotFound, codes.NotFound, "BLOB_NOT_FOUND"},
+	{celestia.ErrHeightFromFuture, codes.OutOfRange, "HEIGHT_FROM_FUTURE"},
+	{celestia.ErrHeightPruned, codes.FailedPrecondition, "HEIGHT_PRUNED"},
+	{celestia.ErrInsufficientFunds, codes.ResourceExhausted, "INSUFFICIENT_FUNDS"},
+	{ErrRateLimited, codes.ResourceExhausted, "RATE_LIMITED"},
+	{ErrQuotaExceeded, codes.ResourceExhausted, "QUOTA_EXCEEDED"},
+	{ErrSubmitKeyReused, codes.InvalidArgument, "SUBMIT_KEY_REUSED"},
+	{ErrInvalidSubmitKey, codes.InvalidArgument, "INVALID_SUBMIT_KEY"},
+	{ErrInvalidLogLevel, codes.InvalidArgument, "INVALID_LOG_LEVEL"},
+	{celestia.ErrBlobTooLarge, codes.InvalidArgument, "BLOB_TOO_LARGE"},
+	{celestia.ErrBlobRejected, codes.InvalidArgument, "BLOB_REJECTED"},
+	{celestia.ErrInvalidRange, codes.InvalidArgument, "INVALID_RANGE"},
+	{celestia.ErrInvalidScanDepth, codes.InvalidArgument, "INVALID_SCAN_DEPTH"},
+	{celestia.ErrInvalidNamespace, codes.InvalidArgument, "INVALID_NAMESPACE"},
+	{celestia.ErrUnsupportedNamespaceVersion, codes.InvalidArgument, "UNSUPPORTED_NAMESPACE_VERSION"},
+	{celestia.ErrReservedNamespace, codes.InvalidArgument, "RESERVED_NAMESPACE"},
+	{celestia.ErrParityNamespace, codes.InvalidArgument, "PARITY_NAMESPACE"},
+	{celestia.ErrTailPaddingNamespace, codes.InvalidArgument, "TAIL_PADDING_NAMESPACE"},
+}
+
+// statusError converts err to a gRPC status error with the code of the first matching entry of
+// errorCodes, and its reason as ErrorInfo detail. Other errors, and errors that already carry a
+// status, are returned as is.
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	for _, e := range errorCodes {
+		if errors.Is(err, e.err) {
+			st, detailErr := status.New(e.code, err.Error()).WithDetails(&errdetails.ErrorInfo{Reason: e.reason, Domain: errorDomain})
+			if detailErr != nil {
+				return status.Error(e.code, err.Error())
+			}
+			return st.Err()
+		}
+	}
+	return err
+}
+
+// UnaryServerInterceptor converts errors of the celestia package returned by unary methods, such as
+// the methods of the DA service, to gRPC status errors with distinct codes:
+//
+//   - ErrBlobNotFound and ErrUnknownTicket to NotFound
+//   - ErrIndexDisabled, ErrLimitsDisabled, ErrQueueDisabled, ErrCacheDisabled and
+//     ErrNamespaceImmutable to Unimplemented
+//   - ErrPaused to Unavailable
+//   - ErrAdminUnauthenticated to Unauthenticated, ErrAdminTokenRequired to PermissionDenied
+//   - ErrHeightFromFuture to OutOfRange
+//   - ErrHeightPruned to FailedPrecondition
+//   - ErrInsufficientFunds, ErrRateLimited and ErrQuotaExceeded to ResourceExhausted
+//   - invalid namespaces, height ranges, scan depths, idempotency keys and log levels,
+//     ErrBlobTooLarge and ErrBlobRejected to InvalidArgument
+//
+// The status carries an ErrorInfo detail identifying the error, which UnaryClientInterceptor maps
+// back to it.
+func UnaryServerInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	resp, err := handler(ctx, req)
+	return resp, statusError(err)
+}
+
+// remoteError is an error received from a celestia-da service that matches an error of the
+// celestia package.
+type remoteError struct {
+	err    error
+	status error
+}
+
+func (e *remoteError) Error() string {
+	return e.status.Error()
+}
+
+func (e *remoteError) Unwrap() []error {
+	return []error{e.err, e.status}
+}
+
+// UnaryClientInterceptor reverses UnaryServerInterceptor, so errors.Is matches the errors of the
+// celestia package for errors returned by a celestia-da service, e.g. through the go-da gRPC client.
+// The gRPC status of the error is kept.
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return clientError(invoker(ctx, method, req, reply, cc, opts...))
+}
+
+// clientError maps a status error to the entry of errorCodes with the reason of its ErrorInfo
+// detail. Status errors without a known reason are returned as is.
+func clientError(err error) error {
+	st, ok := status.FromError(err)
+	if err == nil || !ok {
+		return err
+	}
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.Domain != errorDomain {
+			continue
+		}
+		for _, e := range errorCodes {
+			if e.reason == info.Reason {
+				return &remoteError{err: e.err, status: err}
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	rpc "github.com/celestiaorg/celestia-node/api/rpc/client"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/rollkit/celestia-da/celestia"
+	proxygrpc "github.com/rollkit/go-da/proxy/grpc"
+)
+
+func TestErrorCodes(t *testing.T) {
+	ctx := context.Background()
+	mock := celestia.NewMockService()
+	defer mock.Close()
+
+	ns, err := share.NewBlobNamespaceV0([]byte("errors"))
+	require.NoError(t, err)
+	client, err := rpc.NewClient(ctx, mock.URL(), "")
+	require.NoError(t, err)
+	defer client.Close()
+	c := celestia.NewCelestiaDA(client, ns, -1, ctx)
+	ids, err := c.Submit(ctx, [][]byte{[]byte("blob")}, -1, nil)
+	require.NoError(t, err)
+
+	srv := proxygrpc.NewServer(c, grpc.UnaryInterceptor(UnaryServerInterceptor))
+	daClient := proxygrpc.NewClient()
+	require.NoError(t, daClient.Start(listen(t, srv),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor)))
+	defer daClient.Stop()
+
+	// an unknown commitment at a height with blobs
+	unknown := append(append([]byte{}, ids[0][:8]...), []byte("unknown")...)
+	_, err = daClient.Get(ctx, [][]byte{unknown}, nil)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.ErrorIs(t, err, celestia.ErrBlobNotFound)
+
+	_, err = daClient.GetIDs(ctx, 1, make([]byte, 3))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.ErrorIs(t, err, celestia.ErrReservedNamespace)
+
+	reasons := make(map[string]bool)
+	for _, e := range errorCodes {
+		st := statusError(fmt.Errorf("wrapped: %w", e.err))
+		assert.Equal(t, e.code, status.Code(st), e.err)
+		assert.ErrorIs(t, clientError(st), e.err)
+		assert.False(t, reasons[e.reason], "duplicate reason %s", e.reason)
+		reasons[e.reason] = true
+	}
+	assert.Equal(t, codes.Unknown, status.Code(statusError(errors.New("other"))))
+
+	// errors are identified by their details, not by the message
+	plain := status.Error(codes.NotFound, celestia.ErrBlobNotFound.Error())
+	assert.NotErrorIs(t, clientError(plain), celestia.ErrBlobNotFound)
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	"github.com/rollkit/celestia-da/celestia"
+)
+
+// EstimateFeeRequest holds the blobs to estimate the fee of.
+type EstimateFeeRequest struct {
+	Blobs [][]byte `json:"blobs"`
+	// Namespace defaults to the namespace of the service.
+	Namespace []byte `json:"namespace,omitempty"`
+}
+
+// FeeService estimates the fees of submitting blobs.
+type FeeService interface {
+	Estimate(context.Context, *EstimateFeeRequest) (*celestia.FeeEstimate, error)
+}
+
+// RegisterFeeService registers the fee service for c on srv.
+func RegisterFeeService(srv *grpc.Server, c *celestia.CelestiaDA) {
+	srv.RegisterService(&feeServiceDesc, &feeServer{da: c})
+}
+
+type feeServer struct {
+	da *celestia.CelestiaDA
+}
+
+func (s *feeServer) Estimate(ctx context.Context, req *EstimateFeeRequest) (*celestia.FeeEstimate, error) {
+	estimate, err := s.da.EstimateFee(ctx, req.Blobs, req.Namespace)
+	return estimate, statusError(err)
+}
+
+var feeServiceDesc = grpc.ServiceDesc{
+	ServiceName: "celestiada.v1.Fee",
+	HandlerType: (*FeeService)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("celestiada.v1.Fee", "Estimate", func(srv any, ctx context.Context, req *EstimateFeeRequest) (any, error) {
+			return srv.(FeeService).Estimate(ctx, req)
+		}),
+	},
+}
+
+// FeeClient estimates the fees of submitting blobs.
+type FeeClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewFeeClient returns a client for the fee service served on cc.
+func NewFeeClient(cc grpc.ClientConnInterface) *FeeClient {
+	return &FeeClient{cc: cc}
+}
+
+// Estimate returns the projected gas and fees of submitting the requested blobs, at the gas price
+// configured for the service and at the suggested gas price.
+func (c *FeeClient) Estimate(ctx context.Context, req EstimateFeeRequest, opts ...grpc.CallOption) (*celestia.FeeEstimate, error) {
+	out := new(celestia.FeeEstimate)
+	if err := c.cc.Invoke(ctx, "/celestiada.v1.Fee/Estimate", &req, out, callOptions(opts)...); err != nil {
+		return nil, clientError(err)
+	}
+	return out, nil
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/rollkit/celestia-da/celestia"
+	"github.com/rollkit/go-da"
+	proxygrpc "github.com/rollkit/go-da/proxy/grpc"
+)
+
+// NotFinalMetadata is the header of DA service submissions whose blobs were included, but not
+// confirmed in time with celestia.WithFinality, see NewDAServer.
+const NotFinalMetadata = "celestia-da-not-final"
+
+// NewDAServer returns a gRPC server serving d as the go-da DA service, like proxygrpc.NewServer.
+//
+// Submissions that fail with celestia.ErrNotFinal return the IDs of the included blobs instead of
+// the error, which would drop them, and report the pending confirmation with the NotFinalMetadata
+// header set to "true".
+func NewDAServer(d da.DA, opts ...grpc.ServerOption) *grpc.Server {
+	return proxygrpc.NewServer(&finalityDA{d}, opts...)
+}
+
+// finalityDA returns the IDs of submissions that failed with celestia.ErrNotFinal as a success.
+type finalityDA struct {
+	da.DA
+}
+
+func (d *finalityDA) Submit(ctx context.Context, blobs []da.Blob, gasPrice float64, ns da.Namespace) ([]da.ID, error) {
+	ids, err := d.DA.Submit(ctx, blobs, gasPrice, ns)
+	if !errors.Is(err, celestia.ErrNotFinal) || len(ids) == 0 {
+		return ids, err
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(NotFinalMetadata, "true")); err != nil {
+		log.Println("failed to report unconfirmed submission", "error", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	rpc "github.com/celestiaorg/celestia-node/api/rpc/client"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/rollkit/celestia-da/celestia"
+	proxygrpc "github.com/rollkit/go-da/proxy/grpc"
+)
+
+func TestDAServer_NotFinal(t *testing.T) {
+	ctx := context.Background()
+	mock := celestia.NewMockService()
+	defer mock.Close()
+
+	ns, err := share.NewBlobNamespaceV0([]byte("finality"))
+	require.NoError(t, err)
+	client, err := rpc.NewClient(ctx, mock.URL(), "")
+	require.NoError(t, err)
+	defer client.Close()
+	// the mock never reaches the depth, so submissions time out waiting for their confirmation
+	c, err := celestia.NewCelestiaDAWithOptions(client, ns, -1, ctx,
+		celestia.WithFinality(celestia.FinalityConfig{Timeout: 100 * time.Millisecond, Depth: 10}))
+	require.NoError(t, err)
+
+	dedup := NewDeduplicator(c, DefaultDedupConfig())
+	srv := NewDAServer(c, grpc.ChainUnaryInterceptor(UnaryServerInterceptor, dedup.UnaryServerInterceptor))
+	RegisterReceiptsService(srv, c)
+	addr := listen(t, srv)
+
+	// the header of the last call is recorded by the client interceptor
+	var header metadata.MD
+	daClient := proxygrpc.NewClient()
+	require.NoError(t, daClient.Start(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(UnaryClientInterceptor, func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			header = nil
+			return invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header))...)
+		})))
+	defer func() { _ = daClient.Stop() }()
+
+	keyCtx := WithSubmitKey(ctx, "not final")
+	ids, err := daClient.Submit(keyCtx, [][]byte{[]byte("blob")}, -1, nil)
+	require.NoError(t, err)
+	require.Len(t, ids, 1)
+	assert.Equal(t, []string{"true"}, header.Get(NotFinalMetadata))
+	blobs, err := c.Get(ctx, ids, nil)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("blob")}, blobs)
+
+	// duplicates return the IDs and header of the first submission
+	duplicate, err := daClient.Submit(keyCtx, [][]byte{[]byte("blob")}, -1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ids, duplicate)
+	assert.Equal(t, []string{"true"}, header.Get(NotFinalMetadata))
+
+	_, err = daClient.MaxBlobSize(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, header.Get(NotFinalMetadata))
+
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	result, err := NewReceiptsClient(conn).Submit(ctx, SubmitRequest{Blobs: [][]byte{[]byte("receipt")}, GasPrice: -1})
+	require.NoError(t, err)
+	assert.True(t, result.NotFinal)
+	assert.Len(t, result.IDs, 1)
+	assert.Len(t, result.Receipts, 1)
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+
+	"github.com/rollkit/celestia-da/celestia"
+)
+
+// LookupRequest selects the submissions of a blob by commitment.
+type LookupRequest struct {
+	Commitment []byte `json:"commitment"`
+}
+
+// LookupResponse holds the submissions of a blob, in ascending order of height.
+type LookupResponse struct {
+	Entries []*celestia.IndexEntry `json:"entries"`
+}
+
+// ListRequest selects the submissions to a namespace, starting at a height.
+type ListRequest struct {
+	// Namespace defaults to the namespace of the service.
+	Namespace  []byte `json:"namespace,omitempty"`
+	FromHeight uint64 `json:"from_height"`
+	// Limit is the number of entries to return, 0 returns the default of 100.
+	Limit int `json:"limit,omitempty"`
+}
+
+// IndexService queries the submission index.
+type IndexService interface {
+	Lookup(context.Context, *LookupRequest) (*LookupResponse, error)
+	List(context.Context, *ListRequest) (*celestia.IndexPage, error)
+}
+
+// RegisterIndexService registers the submission index service for c on srv.
+func RegisterIndexService(srv *grpc.Server, c *celestia.CelestiaDA) {
+	srv.RegisterService(&indexServiceDesc, &indexServer{da: c})
+}
+
+type indexServer struct {
+	da *celestia.CelestiaDA
+}
+
+func (s *indexServer) Lookup(_ context.Context, req *LookupRequest) (*LookupResponse, error) {
+	entries, err := s.da.LookupSubmission(req.Commitment)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &LookupResponse{Entries: entries}, nil
+}
+
+func (s *indexServer) List(_ context.Context, req *ListRequest) (*celestia.IndexPage, error) {
+	page, err := s.da.ListSubmissions(req.Namespace, req.FromHeight, req.Limit)
+	return page, statusError(err)
+}
+
+var indexServiceDesc = grpc.ServiceDesc{
+	ServiceName: "celestiada.v1.Index",
+	HandlerType: (*IndexService)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("celestiada.v1.Index", "Lookup", func(srv any, ctx context.Context, req *LookupRequest) (any, error) {
+			return srv.(IndexService).Lookup(ctx, req)
+		}),
+		unaryMethod("celestiada.v1.Index", "List", func(srv any, ctx context.Context, req *ListRequest) (any, error) {
+			return srv.(IndexService).List(ctx, req)
+		}),
+	},
+}
+
+// CallerInterceptor records the address of the client as the caller of unary methods, which the
+// submission index records for submitted blobs.
+func CallerInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ctx = celestia.ContextWithCaller(ctx, p.Addr.String())
+	}
+	return handler(ctx, req)
+}
+
+// IndexClient queries the submission index.
+type IndexClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewIndexClient returns a client for the submission index service served on cc.
+func NewIndexClient(cc grpc.ClientConnInterface) *IndexClient {
+	return &IndexClient{cc: cc}
+}
+
+// Lookup returns the submissions of the blob with the given commitment.
+func (c *IndexClient) Lookup(ctx context.Context, commitment []byte, opts ...grpc.CallOption) ([]*celestia.IndexEntry, error) {
+	out := new(LookupResponse)
+	if err := c.cc.Invoke(ctx, "/celestiada.v1.Index/Lookup", &LookupRequest{Commitment: commitment}, out, callOptions(opts)...); err != nil {
+		return nil, clientError(err)
+	}
+	return out.Entries, nil
+}
+
+// List returns the submissions to a namespace, continue from the returned IndexPage.Next to get the
+// remaining ones.
+func (c *IndexClient) List(ctx context.Context, req ListRequest, opts ...grpc.CallOption) (*celestia.IndexPage, error) {
+	out := new(celestia.IndexPage)
+	if err := c.cc.Invoke(ctx, "/celestiada.v1.Index/List", &req, out, callOptions(opts)...); err != nil {
+		return nil, clientError(err)
+	}
+	return out, nil
+}
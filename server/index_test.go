@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	rpc "github.com/celestiaorg/celestia-node/api/rpc/client"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/rollkit/celestia-da/celestia"
+	proxygrpc "github.com/rollkit/go-da/proxy/grpc"
+)
+
+func TestIndexService(t *testing.T) {
+	ctx := context.Background()
+	mock := celestia.NewMockService()
+	defer mock.Close()
+
+	ns, err := share.NewBlobNamespaceV0([]byte("index"))
+	require.NoError(t, err)
+	client, err := rpc.NewClient(ctx, mock.URL(), "")
+	require.NoError(t, err)
+	defer client.Close()
+	c, err := celestia.NewCelestiaDAWithOptions(client, ns, -1, ctx, celestia.WithIndex(t.TempDir()))
+	require.NoError(t, err)
+	defer c.Close()
+
+	srv := proxygrpc.NewServer(c, grpc.ChainUnaryInterceptor(CallerInterceptor, UnaryServerInterceptor))
+	RegisterIndexService(srv, c)
+	addr := listen(t, srv)
+	daClient := proxygrpc.NewClient()
+	require.NoError(t, daClient.Start(addr, grpc.WithTransportCredentials(insecure.NewCredentials())))
+	defer daClient.Stop()
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	indexClient := NewIndexClient(conn)
+
+	ids, err := daClient.Submit(ctx, [][]byte{[]byte("blob")}, -1, nil)
+	require.NoError(t, err)
+
+	entries, err := indexClient.Lookup(ctx, ids[0][8:])
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, ids[0], entries[0].ID())
+	assert.Contains(t, entries[0].Caller, "127.0.0.1:")
+
+	page, err := indexClient.List(ctx, ListRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, entries, page.Entries)
+
+	// the index of a service without one is unimplemented
+	plain := grpc.NewServer()
+	RegisterIndexService(plain, celestia.NewCelestiaDA(client, ns, -1, ctx))
+	_, err = NewIndexClient(dial(t, plain)).Lookup(ctx, ids[0][8:])
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+	assert.ErrorIs(t, err, celestia.ErrIndexDisabled)
+}
@@ -0,0 +1,560 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+
+	"github.com/rollkit/celestia-da/celestia"
+	"github.com/rollkit/go-da"
+	pbda "github.com/rollkit/go-da/types/pb/da"
+)
+
+var (
+	// ErrRateLimited is returned for requests exceeding the rate limit of a client or namespace.
+	ErrRateLimited = errors.New("rate limit exceeded")
+	// ErrQuotaExceeded is returned for submissions exceeding the daily quota of a client or namespace.
+	ErrQuotaExceeded = errors.New("daily quota exceeded")
+	// ErrLimitsDisabled is returned by usage queries if no limiter is configured.
+	ErrLimitsDisabled = errors.New("rate limits and quotas are disabled")
+)
+
+// Limit configures the rate limit and daily quotas of a client or namespace. Zero values are
+// unlimited.
+type Limit struct {
+	// Rate is the sustained number of requests per second.
+	Rate float64 `json:"rate,omitempty"`
+	// Burst is the number of requests allowed at once, Rate rounded up by default.
+	Burst int `json:"burst,omitempty"`
+	// DailyBytes bounds the size of the blobs submitted per UTC day.
+	DailyBytes uint64 `json:"daily_bytes,omitempty"`
+	// DailyFee bounds the estimated fees of the blobs submitted per UTC day, in utia.
+	DailyFee uint64 `json:"daily_fee,omitempty"`
+}
+
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return max(1, int(math.Ceil(l.Rate)))
+}
+
+// LimitsConfig configures the limits enforced by a Limiter.
+//
+// Clients are limited on every request, namespaces only on submissions.
+type LimitsConfig struct {
+	// Dir stores the daily usage, so quotas survive restarts.
+	Dir string `json:"-"`
+	// Identity returns the identity of the client of a request, by default the host of the caller
+	// recorded by CallerInterceptor, or of the address of the client for requests without caller.
+	Identity func(context.Context) string `json:"-"`
+
+	// Client is the limit of each client without an entry in Clients.
+	Client  Limit            `json:"client"`
+	Clients map[string]Limit `json:"clients,omitempty"`
+	// Namespace is the limit of each namespace without an entry in Namespaces, which are keyed by
+	// namespaces in any format accepted by celestia.ParseNamespace.
+	Namespace  Limit            `json:"namespace"`
+	Namespaces map[string]Limit `json:"namespaces,omitempty"`
+}
+
+// LoadLimitsConfig reads a JSON encoded LimitsConfig from a file.
+func LoadLimitsConfig(path string) (LimitsConfig, error) {
+	var config LimitsConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("invalid limits configuration %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Usage is the usage of a client or namespace on a UTC day.
+type Usage struct {
+	Day   string `json:"day"`
+	Bytes uint64 `json:"bytes"`
+	// Fee is the estimated fee of the submitted blobs, in utia.
+	Fee   uint64 `json:"fee"`
+	Limit Limit  `json:"limit"`
+}
+
+// Usage scopes.
+const (
+	scopeClient    = "client"
+	scopeNamespace = "namespace"
+)
+
+// Limiter enforces per-client and per-namespace rate limits and daily quotas on the requests of a
+// gRPC server, see UnaryServerInterceptor.
+type Limiter struct {
+	da *celestia.CelestiaDA
+	db *leveldb.DB
+
+	// limitsMu guards config and namespaces, which SetLimits replaces.
+	limitsMu   sync.RWMutex
+	config     LimitsConfig
+	namespaces map[string]Limit
+
+	mu        sync.Mutex
+	buckets   map[string]*rate.Limiter
+	lastPrune time.Time
+	day       string
+	usage     map[string]*Usage
+}
+
+// NewLimiter returns a limiter for the services of c, persisting the usage in config.Dir. The limiter
+// must be closed with Close.
+func NewLimiter(c *celestia.CelestiaDA, config LimitsConfig) (*Limiter, error) {
+	if config.Identity == nil {
+		config.Identity = callerHost
+	}
+	namespaces, err := parseNamespaceLimits(config.Namespaces)
+	if err != nil {
+		return nil, err
+	}
+	l := &Limiter{
+		da:         c,
+		config:     config,
+		namespaces: namespaces,
+		buckets:    make(map[string]*rate.Limiter),
+	}
+	db, err := leveldb.OpenFile(config.Dir, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open limiter usage: %w", err)
+	}
+	l.db = db
+	if err := l.rollover(time.Now()); err != nil {
+		return nil, errors.Join(err, db.Close())
+	}
+	return l, nil
+}
+
+// parseNamespaceLimits keys the limits of namespaces by namespace bytes.
+func parseNamespaceLimits(limits map[string]Limit) (map[string]Limit, error) {
+	namespaces := make(map[string]Limit, len(limits))
+	for s, limit := range limits {
+		ns, err := celestia.ParseNamespace(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limited namespace %q: %w", s, err)
+		}
+		namespaces[string(ns)] = limit
+	}
+	return namespaces, nil
+}
+
+// Limits returns the current limits.
+func (l *Limiter) Limits() LimitsConfig {
+	l.limitsMu.RLock()
+	defer l.limitsMu.RUnlock()
+	return l.config
+}
+
+// SetLimits replaces the limits with those of config, keeping the usage of today. Dir and Identity
+// of config are ignored.
+func (l *Limiter) SetLimits(config LimitsConfig) error {
+	namespaces, err := parseNamespaceLimits(config.Namespaces)
+	if err != nil {
+		return err
+	}
+	l.limitsMu.Lock()
+	config.Dir, config.Identity = l.config.Dir, l.config.Identity
+	l.config, l.namespaces = config, namespaces
+	l.limitsMu.Unlock()
+
+	// buckets are recreated with the new rates
+	l.mu.Lock()
+	l.buckets = make(map[string]*rate.Limiter)
+	l.mu.Unlock()
+	log.Println("changed rate limits and quotas")
+	return nil
+}
+
+// Close closes the usage store.
+func (l *Limiter) Close() error {
+	return l.db.Close()
+}
+
+func (l *Limiter) identity(ctx context.Context) string {
+	l.limitsMu.RLock()
+	defer l.limitsMu.RUnlock()
+	return l.config.Identity(ctx)
+}
+
+// callerHost returns the host of the caller recorded by CallerInterceptor, or of the address of the
+// client if there is none, as streams have no caller.
+func callerHost(ctx context.Context) string {
+	if caller := celestia.CallerFromContext(ctx); caller != "" {
+		return host(caller)
+	}
+	return peerHost(ctx)
+}
+
+// peerHost returns the host of the address of the client.
+func peerHost(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	return host(p.Addr.String())
+}
+
+// host returns the host of addr, or addr if it has no port.
+func host(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+func (l *Limiter) clientLimit(client string) Limit {
+	l.limitsMu.RLock()
+	defer l.limitsMu.RUnlock()
+	if limit, ok := l.config.Clients[client]; ok {
+		return limit
+	}
+	return l.config.Client
+}
+
+func (l *Limiter) namespaceLimit(ns []byte) Limit {
+	l.limitsMu.RLock()
+	defer l.limitsMu.RUnlock()
+	if limit, ok := l.namespaces[string(ns)]; ok {
+		return limit
+	}
+	return l.config.Namespace
+}
+
+// UnaryServerInterceptor rejects requests exceeding the rate limit of their client with
+// ErrRateLimited, and submissions exceeding the rate limit of their namespace, or a daily quota of
+// either, with ErrRateLimited or ErrQuotaExceeded.
+//
+// Submissions are charged before they are handled, and refunded for the blobs that weren't
+// included if they fail, see refundable. Submissions resuming a failed one, see Deduplicator, are
+// only charged for the blobs that weren't included. The interceptor must follow
+// UnaryServerInterceptor, so it sees the errors of the celestia package.
+func (l *Limiter) UnaryServerInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	client := l.identity(ctx)
+	clientLimit := l.clientLimit(client)
+	if err := l.allow(scopeClient, client, clientLimit); err != nil {
+		return nil, statusError(err)
+	}
+	blobs, gasPrice, ns, ok := submission(req)
+	if !ok {
+		return handler(ctx, req)
+	}
+	if len(ns) == 0 {
+		ns = l.da.Namespace()
+	}
+	namespace, err := celestia.NamespaceFromBytes(ns)
+	if err != nil {
+		// rejected by the handler
+		return handler(ctx, req)
+	}
+	nsLimit := l.namespaceLimit(namespace)
+	if err := l.allow(scopeNamespace, string(namespace), nsLimit); err != nil {
+		return nil, statusError(err)
+	}
+
+	charged := blobs
+	if progress, ok := celestia.ProgressFromContext(ctx); ok {
+		// the blobs included before a resumed submission failed were charged by it
+		charged = remainingBlobs(blobs, len(progress.IDs), progress.Chunks)
+	}
+	var size, fee uint64
+	for _, b := range charged {
+		size += uint64(len(b))
+	}
+	if len(charged) > 0 && (clientLimit.DailyFee > 0 || nsLimit.DailyFee > 0) {
+		estimate, err := l.da.EstimateFee(ctx, charged, namespace)
+		if err != nil {
+			return nil, statusError(err)
+		}
+		fee = estimate.Fee
+		if gasPrice >= 0 {
+			fee = uint64(math.Ceil(gasPrice * float64(estimate.Gas)))
+		}
+	}
+	charges := []charge{
+		{scope: scopeClient, id: client, limit: clientLimit},
+		{scope: scopeNamespace, id: string(namespace), limit: nsLimit},
+	}
+	if err := l.charge(charges, size, fee); err != nil {
+		return nil, statusError(err)
+	}
+	resp, err := handler(ctx, req)
+	if err != nil && !errors.Is(err, celestia.ErrNotFinal) {
+		refundSize, refundFee := refundable(blobs, fee, resp, err)
+		l.refund(charges, refundSize, refundFee)
+	}
+	return resp, err
+}
+
+// refundable returns the size and fee of the blobs of a failed submission that weren't included.
+// The leading blobs reported as included by a celestia.SubmitError, or by the partial result the
+// receipts service returns along with its status error, consumed blockspace, so only the remaining
+// blobs and the part of the fee that wasn't paid for the included transactions are refunded. Blobs
+// of a transaction that may have been included are refunded, as the node reports rejected
+// transactions the same way.
+func refundable(blobs [][]byte, fee uint64, resp any, err error) (uint64, uint64) {
+	var included int
+	var chunked map[int][]da.ID
+	var receipts []*celestia.Receipt
+	var submitErr *celestia.SubmitError
+	if errors.As(err, &submitErr) {
+		included, chunked, receipts = len(submitErr.Progress.IDs), submitErr.Progress.Chunks, submitErr.Receipts
+	} else if result, ok := resp.(*celestia.SubmitResult); ok && result != nil {
+		included, receipts = len(result.IDs), result.Receipts
+	}
+	var size, paid uint64
+	for _, b := range remainingBlobs(blobs, included, chunked) {
+		size += uint64(len(b))
+	}
+	for _, r := range receipts {
+		paid += r.Fee
+	}
+	return size, fee - min(fee, paid)
+}
+
+// remainingBlobs returns the blobs of a failed submission after the included ones, except the blobs
+// with included chunks, which are resumed rather than submitted again.
+func remainingBlobs(blobs [][]byte, included int, chunked map[int][]da.ID) [][]byte {
+	var remaining [][]byte
+	for i := included; i < len(blobs); i++ {
+		if _, ok := chunked[i]; !ok {
+			remaining = append(remaining, blobs[i])
+		}
+	}
+	return remaining
+}
+
+// StreamServerInterceptor rejects streams exceeding the rate limit of their client with
+// ErrRateLimited.
+func (l *Limiter) StreamServerInterceptor(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	client := l.identity(ss.Context())
+	if err := l.allow(scopeClient, client, l.clientLimit(client)); err != nil {
+		return statusError(err)
+	}
+	return handler(srv, ss)
+}
+
+// submission returns the blobs, gas price and namespace of submission requests.
+func submission(req any) ([][]byte, float64, []byte, bool) {
+	switch req := req.(type) {
+	case *pbda.SubmitRequest:
+		blobs := make([][]byte, len(req.GetBlobs()))
+		for i, b := range req.GetBlobs() {
+			blobs[i] = b.GetValue()
+		}
+		return blobs, req.GetGasPrice(), req.GetNamespace().GetValue(), true
+	case *SubmitRequest:
+		return req.Blobs, req.GasPrice, req.Namespace, true
+	}
+	return nil, 0, nil, false
+}
+
+// limiterIdleTime is the time after which the full token buckets of clients are dropped.
+const limiterIdleTime = time.Minute
+
+// allow takes a token from the bucket of the client or namespace.
+func (l *Limiter) allow(scope, id string, limit Limit) error {
+	if limit.Rate <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if now.Sub(l.lastPrune) > limiterIdleTime {
+		for key, bucket := range l.buckets {
+			if bucket.TokensAt(now) >= float64(bucket.Burst()) {
+				delete(l.buckets, key)
+			}
+		}
+		l.lastPrune = now
+	}
+	key := scope + "/" + id
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = rate.NewLimiter(rate.Limit(limit.Rate), limit.burst())
+		l.buckets[key] = bucket
+	}
+	if !bucket.AllowN(now, 1) {
+		daMetrics.rejections.Add(context.Background(), 1, metric.WithAttributes(attribute.String("scope", scope), attribute.String("reason", "rate")))
+		return fmt.Errorf("%w: %s %s allows %g requests per second", ErrRateLimited, scope, displayID(scope, id), limit.Rate)
+	}
+	return nil
+}
+
+// charge is the usage of a submission charged to a client or namespace.
+type charge struct {
+	scope string
+	id    string
+	limit Limit
+}
+
+// charge adds the size and fee of a submission to the usage of the clients and namespaces, unless
+// a quota would be exceeded.
+func (l *Limiter) charge(charges []charge, size, fee uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.rollover(time.Now()); err != nil {
+		return err
+	}
+	for _, c := range charges {
+		u := l.get(c.scope, c.id)
+		reason := ""
+		switch {
+		case c.limit.DailyBytes > 0 && u.Bytes+size > c.limit.DailyBytes:
+			reason = fmt.Sprintf("%d bytes submitted today, %d bytes allowed", u.Bytes+size, c.limit.DailyBytes)
+		case c.limit.DailyFee > 0 && u.Fee+fee > c.limit.DailyFee:
+			reason = fmt.Sprintf("%d utia spent today, %d utia allowed", u.Fee+fee, c.limit.DailyFee)
+		}
+		if reason != "" {
+			daMetrics.rejections.Add(context.Background(), 1, metric.WithAttributes(attribute.String("scope", c.scope), attribute.String("reason", "quota")))
+			return fmt.Errorf("%w: %s %s: %s", ErrQuotaExceeded, c.scope, displayID(c.scope, c.id), reason)
+		}
+	}
+	return l.add(charges, int64(size), int64(fee))
+}
+
+// refund subtracts the refundable size and fee of a failed submission from the usage.
+func (l *Limiter) refund(charges []charge, size, fee uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_ = l.add(charges, -int64(size), -int64(fee))
+}
+
+// add changes the usage of today and persists it.
+func (l *Limiter) add(charges []charge, size, fee int64) error {
+	batch := new(leveldb.Batch)
+	for _, c := range charges {
+		u := l.get(c.scope, c.id)
+		u.Bytes = uint64(max(0, int64(u.Bytes)+size))
+		u.Fee = uint64(max(0, int64(u.Fee)+fee))
+		value, err := json.Marshal(u)
+		if err != nil {
+			return err
+		}
+		batch.Put(usageKey(u.Day, c.scope, c.id), value)
+		recordUsage(c.scope+"/"+c.id, size, fee)
+	}
+	return l.db.Write(batch, nil)
+}
+
+// recordUsage adds to the usage metrics of the client or namespace with the given usage key.
+func recordUsage(key string, size, fee int64) {
+	scope, id, _ := strings.Cut(key, "/")
+	attrs := metric.WithAttributes(attribute.String("scope", scope), attribute.String("id", displayID(scope, id)))
+	daMetrics.usageBytes.Add(context.Background(), size, attrs)
+	daMetrics.usageFee.Add(context.Background(), fee, attrs)
+}
+
+// get returns the usage of a client or namespace today.
+func (l *Limiter) get(scope, id string) *Usage {
+	key := scope + "/" + id
+	u, ok := l.usage[key]
+	if !ok {
+		u = &Usage{Day: l.day}
+		l.usage[key] = u
+	}
+	return u
+}
+
+// rollover starts a new day if the day of now differs from the current day, loading the usage
+// persisted for it, and deletes the usage of other days.
+func (l *Limiter) rollover(now time.Time) error {
+	day := now.UTC().Format(time.DateOnly)
+	if day == l.day {
+		return nil
+	}
+	usage := make(map[string]*Usage)
+	batch := new(leveldb.Batch)
+	iter := l.db.NewIterator(nil, nil)
+	defer iter.Release()
+	prefix := []byte(day + "/")
+	for iter.Next() {
+		key := iter.Key()
+		if !bytes.HasPrefix(key, prefix) {
+			batch.Delete(bytes.Clone(key))
+			continue
+		}
+		u := new(Usage)
+		if err := json.Unmarshal(iter.Value(), u); err != nil {
+			return err
+		}
+		usage[string(key[len(prefix):])] = u
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if err := l.db.Write(batch, nil); err != nil {
+		return err
+	}
+	// the usage metrics follow the usage of the new day
+	for key, u := range l.usage {
+		recordUsage(key, -int64(u.Bytes), -int64(u.Fee))
+	}
+	for key, u := range usage {
+		recordUsage(key, int64(u.Bytes), int64(u.Fee))
+	}
+	l.day = day
+	l.usage = usage
+	return nil
+}
+
+// usageKey returns the key of the usage of a client or namespace on a day.
+func usageKey(day, scope, id string) []byte {
+	return []byte(day + "/" + scope + "/" + id)
+}
+
+// displayID returns the client or the hex encoded namespace.
+func displayID(scope, id string) string {
+	if scope == scopeNamespace {
+		return hex.EncodeToString([]byte(id))
+	}
+	return id
+}
+
+// Usage returns the usage today of the client, if not empty, and of the namespace, which defaults to
+// the namespace of the service.
+func (l *Limiter) Usage(client string, ns []byte) (clientUsage, nsUsage *Usage, err error) {
+	if len(ns) == 0 {
+		ns = l.da.Namespace()
+	}
+	namespace, err := celestia.NamespaceFromBytes(ns)
+	if err != nil {
+		return nil, nil, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.rollover(time.Now()); err != nil {
+		return nil, nil, err
+	}
+	if client != "" {
+		u := *l.get(scopeClient, client)
+		u.Limit = l.clientLimit(client)
+		clientUsage = &u
+	}
+	u := *l.get(scopeNamespace, string(namespace))
+	u.Limit = l.namespaceLimit(namespace)
+	return clientUsage, &u, nil
+}
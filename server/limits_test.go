@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	rpc "github.com/celestiaorg/celestia-node/api/rpc/client"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/rollkit/celestia-da/celestia"
+	"github.com/rollkit/go-da"
+	proxygrpc "github.com/rollkit/go-da/proxy/grpc"
+	pbda "github.com/rollkit/go-da/types/pb/da"
+)
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+	mock := celestia.NewMockService()
+	defer mock.Close()
+
+	ns, err := share.NewBlobNamespaceV0([]byte("limits"))
+	require.NoError(t, err)
+	client, err := rpc.NewClient(ctx, mock.URL(), "")
+	require.NoError(t, err)
+	defer client.Close()
+	c := celestia.NewCelestiaDA(client, ns, -1, ctx)
+	estimate, err := c.EstimateFee(ctx, [][]byte{[]byte("blob")}, nil)
+	require.NoError(t, err)
+
+	// start serves c with a limiter for config, and returns clients for the DA and admin services
+	start := func(t *testing.T, config LimitsConfig) (*proxygrpc.Client, *AdminClient, *Limiter) {
+		limiter, err := NewLimiter(c, config)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = limiter.Close() })
+		srv := proxygrpc.NewServer(c,
+			grpc.ChainUnaryInterceptor(UnaryServerInterceptor, limiter.UnaryServerInterceptor),
+			grpc.StreamInterceptor(limiter.StreamServerInterceptor))
+		RegisterAdminService(srv, c, AdminConfig{Token: "secret", Limiter: limiter})
+		addr := listen(t, srv)
+		daClient := proxygrpc.NewClient()
+		require.NoError(t, daClient.Start(addr,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithUnaryInterceptor(UnaryClientInterceptor)))
+		t.Cleanup(func() { _ = daClient.Stop() })
+		conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+		return daClient, NewAdminClient(conn, AdminToken("secret")), limiter
+	}
+
+	t.Run("Rate", func(t *testing.T) {
+		daClient, _, _ := start(t, LimitsConfig{Dir: t.TempDir(), Client: Limit{Rate: 0.01, Burst: 2}})
+		for i := 0; i < 2; i++ {
+			_, err := daClient.MaxBlobSize(ctx)
+			require.NoError(t, err)
+		}
+		_, err := daClient.MaxBlobSize(ctx)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.ErrorIs(t, err, ErrRateLimited)
+		assert.NotErrorIs(t, err, celestia.ErrInsufficientFunds)
+	})
+
+	t.Run("Bytes", func(t *testing.T) {
+		dir := t.TempDir()
+		config := LimitsConfig{Dir: dir, Namespaces: map[string]Limit{"6c696d697473": {DailyBytes: 10}}}
+		daClient, admin, limiter := start(t, config)
+		_, err := daClient.Submit(ctx, [][]byte{[]byte("first")}, -1, nil)
+		require.NoError(t, err)
+		_, err = daClient.Submit(ctx, [][]byte{[]byte("second")}, -1, nil)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.ErrorIs(t, err, ErrQuotaExceeded)
+
+		// failed submissions are refunded, the mock account can't pay this gas price
+		_, err = daClient.Submit(ctx, [][]byte{[]byte("third")}, 1e9, nil)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrQuotaExceeded)
+
+		usage, err := admin.Usage(ctx, UsageRequest{Client: "127.0.0.1"})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(len("first")), usage.Client.Bytes)
+		assert.Equal(t, uint64(len("first")), usage.Namespace.Bytes)
+		assert.Equal(t, uint64(10), usage.Namespace.Limit.DailyBytes)
+
+		// usage survives restarts
+		require.NoError(t, limiter.Close())
+		restarted, err := NewLimiter(c, config)
+		require.NoError(t, err)
+		defer restarted.Close()
+		_, nsUsage, err := restarted.Usage("", nil)
+		require.NoError(t, err)
+		assert.Equal(t, usage.Namespace, nsUsage)
+	})
+
+	t.Run("Fee", func(t *testing.T) {
+		daClient, admin, _ := start(t, LimitsConfig{Dir: t.TempDir(), Client: Limit{DailyFee: estimate.SuggestedFee * 3 / 2}})
+		_, err := daClient.Submit(ctx, [][]byte{[]byte("blob")}, -1, nil)
+		require.NoError(t, err)
+		_, err = daClient.Submit(ctx, [][]byte{[]byte("blob")}, -1, nil)
+		assert.ErrorIs(t, err, ErrQuotaExceeded)
+
+		usage, err := admin.Usage(ctx, UsageRequest{Client: "127.0.0.1"})
+		require.NoError(t, err)
+		assert.Equal(t, estimate.SuggestedFee, usage.Client.Fee)
+	})
+
+	t.Run("Partial", func(t *testing.T) {
+		_, _, limiter := start(t, LimitsConfig{Dir: t.TempDir(), Client: Limit{DailyFee: 1e9}})
+		info := &grpc.UnaryServerInfo{FullMethod: "/da.DAService/Submit"}
+		req := &pbda.SubmitRequest{Blobs: []*pbda.Blob{{Value: []byte("first")}, {Value: []byte("second")}}, GasPrice: -1}
+		submit := func(caller string, progress celestia.SubmitProgress) *Usage {
+			// the client is identified by the host of the caller
+			callerCtx := celestia.ContextWithCaller(ctx, caller+":4000")
+			_, err := limiter.UnaryServerInterceptor(callerCtx, req, info, func(context.Context, any) (any, error) {
+				return nil, &celestia.SubmitError{
+					Progress: progress,
+					Receipts: []*celestia.Receipt{{Fee: 1}},
+					Blobs:    2,
+					Err:      errors.New("failed"),
+				}
+			})
+			require.Error(t, err)
+			usage, _, err := limiter.Usage(caller, nil)
+			require.NoError(t, err)
+			return usage
+		}
+
+		// the included blob and the fee paid for it aren't refunded
+		usage := submit("10.0.0.1", celestia.SubmitProgress{IDs: []da.ID{[]byte("id")}})
+		assert.Equal(t, uint64(len("first")), usage.Bytes)
+		assert.Equal(t, uint64(1), usage.Fee)
+
+		// the blob of a chunked blob whose chunks were included isn't refunded either
+		usage = submit("10.0.0.2", celestia.SubmitProgress{Chunks: map[int][]da.ID{1: {[]byte("chunk")}}})
+		assert.Equal(t, uint64(len("second")), usage.Bytes)
+
+		// a resumed submission is only charged for the blob that wasn't included
+		resumed := celestia.ContextWithProgress(celestia.ContextWithCaller(ctx, "10.0.0.3:4000"), celestia.SubmitProgress{IDs: []da.ID{[]byte("id")}})
+		_, err := limiter.UnaryServerInterceptor(resumed, req, info, func(context.Context, any) (any, error) {
+			return &pbda.SubmitResponse{}, nil
+		})
+		require.NoError(t, err)
+		usage, _, err = limiter.Usage("10.0.0.3", nil)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(len("second")), usage.Bytes)
+	})
+
+	// the usage of a service without limiter is unimplemented
+	plain := grpc.NewServer()
+	RegisterAdminService(plain, c, AdminConfig{Token: "secret"})
+	_, err = NewAdminClient(dial(t, plain), AdminToken("secret")).Usage(ctx, UsageRequest{})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+	assert.ErrorIs(t, err, ErrLimitsDisabled)
+}
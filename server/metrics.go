@@ -0,0 +1,30 @@
+package server
+
+import (
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/rollkit/celestia-da/internal/telemetry"
+)
+
+// daMetrics are the instruments shared by all services.
+var daMetrics = newMetrics()
+
+type metrics struct {
+	rejections metric.Int64Counter
+	usageBytes metric.Int64UpDownCounter
+	usageFee   metric.Int64UpDownCounter
+	duplicates metric.Int64Counter
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		rejections: telemetry.Int64Counter("celestia_da_limit_rejections",
+			metric.WithDescription("Number of requests rejected by rate limits and quotas by scope and reason")),
+		usageBytes: telemetry.Int64UpDownCounter("celestia_da_limit_usage_bytes",
+			metric.WithDescription("Size of blobs charged to the daily quotas of clients and namespaces"), metric.WithUnit("By")),
+		usageFee: telemetry.Int64UpDownCounter("celestia_da_limit_usage_fee",
+			metric.WithDescription("Estimated fees in utia charged to the daily quotas of clients and namespaces")),
+		duplicates: telemetry.Int64Counter("celestia_da_submit_duplicates",
+			metric.WithDescription("Number of duplicate submissions answered with the result of the first by key source")),
+	}
+}
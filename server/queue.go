@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc"
+
+	"github.com/rollkit/celestia-da/celestia"
+)
+
+// StatusRequest selects a queued submission by its ticket.
+type StatusRequest struct {
+	Ticket string `json:"ticket"`
+}
+
+// QueueService serves the status of queued submissions.
+type QueueService interface {
+	// Status returns the current status of a submission.
+	Status(context.Context, *StatusRequest) (*celestia.SubmissionStatus, error)
+	// Watch streams the status of a submission until it is final.
+	Watch(*StatusRequest, grpc.ServerStream) error
+}
+
+// RegisterQueueService registers the queue service for q on srv.
+func RegisterQueueService(srv *grpc.Server, q *celestia.Queue) {
+	srv.RegisterService(&queueServiceDesc, &queueServer{queue: q})
+}
+
+type queueServer struct {
+	queue *celestia.Queue
+}
+
+func (s *queueServer) Status(_ context.Context, req *StatusRequest) (*celestia.SubmissionStatus, error) {
+	st, err := s.queue.Status(req.Ticket)
+	return st, statusError(err)
+}
+
+func (s *queueServer) Watch(req *StatusRequest, stream grpc.ServerStream) error {
+	updates, err := s.queue.Watch(stream.Context(), req.Ticket)
+	if err != nil {
+		return statusError(err)
+	}
+	for st := range updates {
+		if err := stream.SendMsg(&st); err != nil {
+			return err
+		}
+	}
+	return stream.Context().Err()
+}
+
+var queueServiceDesc = grpc.ServiceDesc{
+	ServiceName: "celestiada.v1.Queue",
+	HandlerType: (*QueueService)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("celestiada.v1.Queue", "Status", func(srv any, ctx context.Context, req *StatusRequest) (any, error) {
+			return srv.(QueueService).Status(ctx, req)
+		}),
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "Watch",
+			Handler: func(srv any, stream grpc.ServerStream) error {
+				req := new(StatusRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return srv.(QueueService).Watch(req, stream)
+			},
+			ServerStreams: true,
+		},
+	},
+}
+
+// QueueClient queries the status of queued submissions.
+type QueueClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewQueueClient returns a client for the queue service served on cc.
+func NewQueueClient(cc grpc.ClientConnInterface) *QueueClient {
+	return &QueueClient{cc: cc}
+}
+
+// Status returns the current status of the submission with the given ticket.
+func (c *QueueClient) Status(ctx context.Context, ticket string, opts ...grpc.CallOption) (*celestia.SubmissionStatus, error) {
+	out := new(celestia.SubmissionStatus)
+	err := c.cc.Invoke(ctx, "/celestiada.v1.Queue/Status", &StatusRequest{Ticket: ticket}, out, callOptions(opts)...)
+	if err != nil {
+		return nil, clientError(err)
+	}
+	return out, nil
+}
+
+// Watch calls fn with the status of the submission with the given ticket, and every change of it,
+// until the submission is final.
+func (c *QueueClient) Watch(ctx context.Context, ticket string, fn func(*celestia.SubmissionStatus) error, opts ...grpc.CallOption) error {
+	stream, err := c.cc.NewStream(ctx, &queueServiceDesc.Streams[0], "/celestiada.v1.Queue/Watch", callOptions(opts)...)
+	if err != nil {
+		return err
+	}
+	if err := stream.SendMsg(&StatusRequest{Ticket: ticket}); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	for {
+		st := new(celestia.SubmissionStatus)
+		if err := stream.RecvMsg(st); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return clientError(err)
+		}
+		if err := fn(st); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	rpc "github.com/celestiaorg/celestia-node/api/rpc/client"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/rollkit/celestia-da/celestia"
+)
+
+// dial serves srv over an in-memory listener and returns a connection to it.
+func dial(t *testing.T, srv *grpc.Server) *grpc.ClientConn {
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// listen serves srv on a local TCP port and returns its address.
+func listen(t *testing.T, srv *grpc.Server) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func TestQueueService(t *testing.T) {
+	ctx := context.Background()
+	ns, err := share.NewBlobNamespaceV0([]byte("queue"))
+	require.NoError(t, err)
+	// the node is unreachable, so submissions stay pending
+	client, err := rpc.NewClient(ctx, "http://127.0.0.1:1", "")
+	require.NoError(t, err)
+	defer client.Close()
+
+	config := celestia.DefaultQueueConfig(t.TempDir())
+	config.MaxAttempts = 2
+	config.RetryInterval = 10 * time.Millisecond
+	queue, err := celestia.NewQueue(celestia.NewCelestiaDA(client, ns, -1, ctx), config)
+	require.NoError(t, err)
+	defer queue.Close()
+
+	srv := grpc.NewServer()
+	RegisterQueueService(srv, queue)
+	queueClient := NewQueueClient(dial(t, srv))
+
+	ids, err := queue.Submit(ctx, [][]byte{[]byte("blob")}, -1, nil)
+	require.NoError(t, err)
+	ticket, _, ok := celestia.TicketFromID(ids[0])
+	require.True(t, ok)
+
+	var updates []*celestia.SubmissionStatus
+	err = queueClient.Watch(ctx, ticket, func(st *celestia.SubmissionStatus) error {
+		updates = append(updates, st)
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, updates)
+	last := updates[len(updates)-1]
+	assert.Equal(t, celestia.SubmissionFailed, last.State)
+	assert.Equal(t, 2, last.Attempts)
+
+	st, err := queueClient.Status(ctx, ticket)
+	require.NoError(t, err)
+	assert.Equal(t, last, st)
+
+	_, err = queueClient.Status(ctx, "unknown")
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	err = queueClient.Watch(ctx, "unknown", func(*celestia.SubmissionStatus) error { return nil })
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	"github.com/rollkit/celestia-da/celestia"
+)
+
+// RangeRequest selects the namespace and heights of a range query.
+type RangeRequest struct {
+	// Namespace defaults to the namespace of the service.
+	Namespace []byte `json:"namespace,omitempty"`
+	// From is the first height of the range.
+	From uint64 `json:"from"`
+	// To is the last height of the range, inclusive.
+	To uint64 `json:"to"`
+}
+
+// RangeService returns the blobs in a namespace over a range of heights.
+type RangeService interface {
+	GetIDs(context.Context, *RangeRequest) (*celestia.RangeResult, error)
+	GetAll(context.Context, *RangeRequest) (*celestia.RangeResult, error)
+}
+
+// RegisterRangeService registers the range service for c on srv.
+func RegisterRangeService(srv *grpc.Server, c *celestia.CelestiaDA) {
+	srv.RegisterService(&rangeServiceDesc, &rangeServer{da: c})
+}
+
+type rangeServer struct {
+	da *celestia.CelestiaDA
+}
+
+func (s *rangeServer) GetIDs(ctx context.Context, req *RangeRequest) (*celestia.RangeResult, error) {
+	result, err := s.da.GetIDsRange(ctx, req.From, req.To, req.Namespace)
+	return result, statusError(err)
+}
+
+func (s *rangeServer) GetAll(ctx context.Context, req *RangeRequest) (*celestia.RangeResult, error) {
+	result, err := s.da.GetAllRange(ctx, req.From, req.To, req.Namespace)
+	return result, statusError(err)
+}
+
+var rangeServiceDesc = grpc.ServiceDesc{
+	ServiceName: "celestiada.v1.Range",
+	HandlerType: (*RangeService)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("celestiada.v1.Range", "GetIDs", func(srv any, ctx context.Context, req *RangeRequest) (any, error) {
+			return srv.(RangeService).GetIDs(ctx, req)
+		}),
+		unaryMethod("celestiada.v1.Range", "GetAll", func(srv any, ctx context.Context, req *RangeRequest) (any, error) {
+			return srv.(RangeService).GetAll(ctx, req)
+		}),
+	},
+}
+
+// RangeClient queries the blobs in a namespace over a range of heights.
+type RangeClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewRangeClient returns a client for the range service served on cc.
+func NewRangeClient(cc grpc.ClientConnInterface) *RangeClient {
+	return &RangeClient{cc: cc}
+}
+
+// GetIDs returns the IDs in the namespace over the requested range, continue from the returned
+// RangeResult.Next to get the remaining heights.
+func (c *RangeClient) GetIDs(ctx context.Context, req RangeRequest, opts ...grpc.CallOption) (*celestia.RangeResult, error) {
+	result := new(celestia.RangeResult)
+	if err := c.cc.Invoke(ctx, "/celestiada.v1.Range/GetIDs", &req, result, callOptions(opts)...); err != nil {
+		return nil, clientError(err)
+	}
+	return result, nil
+}
+
+// GetAll returns the IDs and blobs in the namespace over the requested range, continue from the
+// returned RangeResult.Next to get the remaining heights.
+func (c *RangeClient) GetAll(ctx context.Context, req RangeRequest, opts ...grpc.CallOption) (*celestia.RangeResult, error) {
+	result := new(celestia.RangeResult)
+	if err := c.cc.Invoke(ctx, "/celestiada.v1.Range/GetAll", &req, result, callOptions(opts)...); err != nil {
+		return nil, clientError(err)
+	}
+	return result, nil
+}
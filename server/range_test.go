@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	rpc "github.com/celestiaorg/celestia-node/api/rpc/client"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/rollkit/celestia-da/celestia"
+)
+
+func TestRangeService(t *testing.T) {
+	ctx := context.Background()
+	mock := celestia.NewMockService()
+	defer mock.Close()
+
+	ns, err := share.NewBlobNamespaceV0([]byte("range"))
+	require.NoError(t, err)
+	client, err := rpc.NewClient(ctx, mock.URL(), "")
+	require.NoError(t, err)
+	defer client.Close()
+	c := celestia.NewCelestiaDA(client, ns, -1, ctx)
+
+	ids, err := c.Submit(ctx, [][]byte{[]byte("blob")}, -1, nil)
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	RegisterRangeService(srv, c)
+	rangeClient := NewRangeClient(dial(t, srv))
+
+	result, err := rangeClient.GetAll(ctx, RangeRequest{From: 1, To: 10})
+	require.NoError(t, err)
+	require.Len(t, result.Heights, 1)
+	assert.Equal(t, ids, result.Heights[0].IDs)
+	assert.Equal(t, [][]byte{[]byte("blob")}, result.Heights[0].Blobs)
+	assert.Equal(t, uint64(2), result.Next)
+
+	_, err = rangeClient.GetIDs(ctx, RangeRequest{From: 2, To: 1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCommitmentService(t *testing.T) {
+	ctx := context.Background()
+	mock := celestia.NewMockService()
+	defer mock.Close()
+
+	ns, err := share.NewBlobNamespaceV0([]byte("commitment"))
+	require.NoError(t, err)
+	client, err := rpc.NewClient(ctx, mock.URL(), "")
+	require.NoError(t, err)
+	defer client.Close()
+	c := celestia.NewCelestiaDA(client, ns, -1, ctx)
+	ids, err := c.Submit(ctx, [][]byte{[]byte("blob")}, -1, nil)
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	RegisterCommitmentService(srv, c)
+	commitmentClient := NewCommitmentClient(dial(t, srv))
+
+	result, err := commitmentClient.Get(ctx, CommitmentRequest{Commitment: ids[0][8:]})
+	require.NoError(t, err)
+	assert.Equal(t, ids[0], []byte(result.ID))
+	assert.Equal(t, []byte("blob"), []byte(result.Blob))
+
+	_, err = commitmentClient.Get(ctx, CommitmentRequest{Commitment: []byte("unknown")})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.ErrorIs(t, err, celestia.ErrBlobNotFound)
+	_, err = commitmentClient.Get(ctx, CommitmentRequest{Commitment: ids[0][8:], ScanDepth: 1 << 20})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	"github.com/rollkit/celestia-da/celestia"
+)
+
+// SubmitRequest holds the arguments of a submission with receipts.
+type SubmitRequest struct {
+	Blobs [][]byte `json:"blobs"`
+	// GasPrice in utia/gas, negative values use the default fees.
+	GasPrice float64 `json:"gas_price"`
+	// Namespace defaults to the namespace of the service.
+	Namespace []byte `json:"namespace,omitempty"`
+}
+
+// ReceiptsService submits blobs and returns the receipts of their transactions.
+type ReceiptsService interface {
+	Submit(context.Context, *SubmitRequest) (*celestia.SubmitResult, error)
+}
+
+// RegisterReceiptsService registers the receipts service for c on srv.
+func RegisterReceiptsService(srv *grpc.Server, c *celestia.CelestiaDA) {
+	srv.RegisterService(&receiptsServiceDesc, &receiptsServer{da: c})
+}
+
+type receiptsServer struct {
+	da *celestia.CelestiaDA
+}
+
+func (s *receiptsServer) Submit(ctx context.Context, req *SubmitRequest) (*celestia.SubmitResult, error) {
+	result, err := s.da.SubmitWithReceipts(ctx, req.Blobs, req.GasPrice, req.Namespace)
+	if result != nil && result.NotFinal {
+		// the blobs were included, which a status error would hide
+		return result, nil
+	}
+	return result, statusError(err)
+}
+
+var receiptsServiceDesc = grpc.ServiceDesc{
+	ServiceName: "celestiada.v1.Receipts",
+	HandlerType: (*ReceiptsService)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("celestiada.v1.Receipts", "Submit", func(srv any, ctx context.Context, req *SubmitRequest) (any, error) {
+			return srv.(ReceiptsService).Submit(ctx, req)
+		}),
+	},
+}
+
+// ReceiptsClient submits blobs and returns the receipts of their transactions.
+type ReceiptsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewReceiptsClient returns a client for the receipts service served on cc.
+func NewReceiptsClient(cc grpc.ClientConnInterface) *ReceiptsClient {
+	return &ReceiptsClient{cc: cc}
+}
+
+// Submit submits the requested blobs like the Submit method of the DA service, and returns their
+// IDs along with a receipt per transaction. Blobs that were included, but not confirmed in time
+// with finality, are returned with NotFinal set.
+func (c *ReceiptsClient) Submit(ctx context.Context, req SubmitRequest, opts ...grpc.CallOption) (*celestia.SubmitResult, error) {
+	out := new(celestia.SubmitResult)
+	if err := c.cc.Invoke(ctx, "/celestiada.v1.Receipts/Submit", &req, out, callOptions(opts)...); err != nil {
+		return nil, clientError(err)
+	}
+	return out, nil
+}
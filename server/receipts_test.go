@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	rpc "github.com/celestiaorg/celestia-node/api/rpc/client"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/rollkit/celestia-da/celestia"
+)
+
+func TestReceiptsService(t *testing.T) {
+	ctx := context.Background()
+	mock := celestia.NewMockService()
+	defer mock.Close()
+
+	ns, err := share.NewBlobNamespaceV0([]byte("receipts"))
+	require.NoError(t, err)
+	client, err := rpc.NewClient(ctx, mock.URL(), "")
+	require.NoError(t, err)
+	defer client.Close()
+	c := celestia.NewCelestiaDA(client, ns, -1, ctx)
+
+	srv := grpc.NewServer(grpc.UnaryInterceptor(UnaryServerInterceptor))
+	RegisterReceiptsService(srv, c)
+	receipts := NewReceiptsClient(dial(t, srv))
+
+	result, err := receipts.Submit(ctx, SubmitRequest{Blobs: [][]byte{[]byte("first"), []byte("second")}, GasPrice: -1})
+	require.NoError(t, err)
+	require.Len(t, result.IDs, 2)
+	require.Len(t, result.Receipts, 1)
+	assert.NotEmpty(t, result.Receipts[0].TxHash)
+	assert.NotZero(t, result.Receipts[0].Fee)
+	assert.Equal(t, result.IDs[1], result.Receipts[0].Blobs[1].ID)
+	assert.Equal(t, &celestia.ShareRange{Start: 2, End: 3}, result.Receipts[0].Blobs[1].Shares)
+
+	blobs, err := c.Get(ctx, result.IDs, nil)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("first"), []byte("second")}, blobs)
+
+	_, err = receipts.Submit(ctx, SubmitRequest{Blobs: [][]byte{[]byte("blob")}, Namespace: make([]byte, 30)})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestFeeService(t *testing.T) {
+	ctx := context.Background()
+	mock := celestia.NewMockService()
+	defer mock.Close()
+
+	ns, err := share.NewBlobNamespaceV0([]byte("fee"))
+	require.NoError(t, err)
+	client, err := rpc.NewClient(ctx, mock.URL(), "")
+	require.NoError(t, err)
+	defer client.Close()
+	c := celestia.NewCelestiaDA(client, ns, 0.1, ctx)
+
+	srv := grpc.NewServer(grpc.UnaryInterceptor(UnaryServerInterceptor))
+	RegisterFeeService(srv, c)
+	fees := NewFeeClient(dial(t, srv))
+
+	blobs := [][]byte{[]byte("first"), []byte("second")}
+	estimate, err := fees.Estimate(ctx, EstimateFeeRequest{Blobs: blobs})
+	require.NoError(t, err)
+	expected, err := c.EstimateFee(ctx, blobs, nil)
+	require.NoError(t, err)
+	assert.Equal(t, expected, estimate)
+	assert.Equal(t, 0.1, estimate.GasPrice)
+
+	_, err = fees.Estimate(ctx, EstimateFeeRequest{Blobs: blobs, Namespace: make([]byte, 30)})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
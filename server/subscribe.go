@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/rollkit/celestia-da/celestia"
+)
+
+// SubscribeRequest selects the namespace and first height of a subscription.
+type SubscribeRequest struct {
+	// Namespace defaults to the namespace of the service.
+	Namespace []byte `json:"namespace,omitempty"`
+	// FromHeight is the first height to send, 0 starts after the current head.
+	FromHeight uint64 `json:"from_height"`
+	// Blobs requests the blobs in addition to their IDs.
+	Blobs bool `json:"blobs"`
+}
+
+// SubscriptionService streams the blobs in a namespace at new heights.
+type SubscriptionService interface {
+	Subscribe(*SubscribeRequest, grpc.ServerStream) error
+}
+
+// RegisterSubscriptionService registers the subscription service for c on srv.
+func RegisterSubscriptionService(srv *grpc.Server, c *celestia.CelestiaDA) {
+	srv.RegisterService(&subscriptionServiceDesc, &subscriptionServer{da: c})
+}
+
+type subscriptionServer struct {
+	da *celestia.CelestiaDA
+}
+
+func (s *subscriptionServer) Subscribe(req *SubscribeRequest, stream grpc.ServerStream) error {
+	results, err := s.da.Subscribe(stream.Context(), req.FromHeight, req.Namespace, req.Blobs)
+	if err != nil {
+		return statusError(err)
+	}
+	for result := range results {
+		if err := stream.SendMsg(result); err != nil {
+			return err
+		}
+	}
+	return stream.Context().Err()
+}
+
+var subscriptionServiceDesc = grpc.ServiceDesc{
+	ServiceName: "celestiada.v1.Subscription",
+	HandlerType: (*SubscriptionService)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "Subscribe",
+			Handler: func(srv any, stream grpc.ServerStream) error {
+				req := new(SubscribeRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return srv.(SubscriptionService).Subscribe(req, stream)
+			},
+			ServerStreams: true,
+		},
+	},
+}
+
+const (
+	// resubscribeInterval is the delay before the first attempt to resubscribe after the stream
+	// failed, doubled after each further attempt.
+	resubscribeInterval = time.Second
+	// maxResubscribeInterval bounds the delay between attempts to resubscribe.
+	maxResubscribeInterval = 30 * time.Second
+)
+
+// SubscriptionClient subscribes to the blobs in a namespace at new heights.
+type SubscriptionClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewSubscriptionClient returns a client for the subscription service served on cc.
+func NewSubscriptionClient(cc grpc.ClientConnInterface) *SubscriptionClient {
+	return &SubscriptionClient{cc: cc}
+}
+
+// Subscribe calls fn for every height starting at req.FromHeight, in order and without gaps, until
+// ctx is done or fn returns an error.
+//
+// When the stream fails, e.g. because the service restarted, Subscribe resubscribes from the height
+// after the last one passed to fn. Only errors from fn, and ctx errors, are returned.
+func (c *SubscriptionClient) Subscribe(ctx context.Context, req SubscribeRequest, fn func(*celestia.HeightBlobs) error, opts ...grpc.CallOption) error {
+	delay := resubscribeInterval
+	for {
+		received, err := c.subscribe(ctx, &req, fn, opts)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var fnErr callbackError
+		if errors.As(err, &fnErr) {
+			return fnErr.err
+		}
+		if code := status.Code(err); code == codes.InvalidArgument || code == codes.Unimplemented || code == codes.PermissionDenied || code == codes.Unauthenticated {
+			return err
+		}
+		if received {
+			delay = resubscribeInterval
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay = min(2*delay, maxResubscribeInterval)
+	}
+}
+
+// callbackError wraps errors returned by the subscription callback.
+type callbackError struct {
+	err error
+}
+
+func (e callbackError) Error() string {
+	return e.err.Error()
+}
+
+// subscribe runs a single subscription stream, advancing req.FromHeight after each received height.
+// It returns true if at least one height was received.
+func (c *SubscriptionClient) subscribe(ctx context.Context, req *SubscribeRequest, fn func(*celestia.HeightBlobs) error, opts []grpc.CallOption) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.cc.NewStream(ctx, &subscriptionServiceDesc.Streams[0], "/celestiada.v1.Subscription/Subscribe", callOptions(opts)...)
+	if err != nil {
+		return false, err
+	}
+	if err := stream.SendMsg(req); err != nil {
+		return false, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return false, err
+	}
+	received := false
+	for {
+		result := new(celestia.HeightBlobs)
+		if err := stream.RecvMsg(result); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = errors.New("subscription stream ended")
+			}
+			return received, err
+		}
+		received = true
+		if err := fn(result); err != nil {
+			return received, callbackError{err: err}
+		}
+		req.FromHeight = result.Height + 1
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	rpc "github.com/celestiaorg/celestia-node/api/rpc/client"
+	"github.com/celestiaorg/celestia-node/share"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/rollkit/celestia-da/celestia"
+)
+
+func TestSubscriptionService(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	mock := celestia.NewMockService()
+	defer mock.Close()
+	client, err := rpc.NewClient(ctx, mock.URL(), "")
+	require.NoError(t, err)
+	defer client.Close()
+	ns, err := share.NewBlobNamespaceV0([]byte("subscribe"))
+	require.NoError(t, err)
+	da := celestia.NewCelestiaDA(client, ns, -1, ctx)
+
+	// the client reconnects to whichever server is currently listening
+	var mu sync.Mutex
+	var lis *bufconn.Listener
+	start := func() *grpc.Server {
+		srv := grpc.NewServer()
+		RegisterSubscriptionService(srv, da)
+		mu.Lock()
+		lis = bufconn.Listen(1 << 20)
+		go func(lis *bufconn.Listener) { _ = srv.Serve(lis) }(lis)
+		mu.Unlock()
+		return srv
+	}
+	srv := start()
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var ids [][]byte
+	for i := 0; i < 3; i++ {
+		submitted, err := da.Submit(ctx, [][]byte{{byte(i)}}, -1, nil)
+		require.NoError(t, err)
+		ids = append(ids, submitted[0])
+	}
+
+	var heights []uint64
+	errDone := errors.New("done")
+	err = NewSubscriptionClient(conn).Subscribe(ctx, SubscribeRequest{FromHeight: 1, Blobs: true}, func(result *celestia.HeightBlobs) error {
+		heights = append(heights, result.Height)
+		assert.Equal(t, [][]byte{ids[result.Height-1]}, result.IDs)
+		assert.Equal(t, [][]byte{{byte(result.Height - 1)}}, result.Blobs)
+		switch result.Height {
+		case 1:
+			// the subscription resumes at height 2 after the server restarts
+			srv.Stop()
+			srv = start()
+		case 3:
+			return errDone
+		}
+		return nil
+	})
+	srv.Stop()
+	assert.ErrorIs(t, err, errDone)
+	assert.Equal(t, []uint64{1, 2, 3}, heights)
+}